@@ -79,7 +79,7 @@ var Languages = map[string]LangConfig{
 		IncludeRegex: `import (.+?)??("|')(.+?)("|')(as)?(.+)?;`,
 		CompileCmd: []string{
 			"solc",
-			"--combined-json", "bin,abi",
+			"--combined-json", "bin,abi,bin-runtime",
 			"_",
 		},
 	},
@@ -87,8 +87,9 @@ var Languages = map[string]LangConfig{
 
 // individual contract items
 type SolcItem struct {
-	Bin string `json:"bin"`
-	Abi string `json:"abi"`
+	Bin        string `json:"bin"`
+	Abi        string `json:"abi"`
+	BinRuntime string `json:"bin-runtime"`
 }
 
 // full solc response object
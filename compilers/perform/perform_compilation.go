@@ -33,6 +33,8 @@ type ResponseItem struct {
 	Objectname string `json:"objectname"`
 	Bytecode   string `json:"bytecode"`
 	ABI        string `json:"abi"` // json encoded
+	// the code left on chain once the contract is deployed
+	RuntimeBytecode string `json:"runtimeBytecode"`
 }
 
 func (resp Response) CacheNewResponse(req definitions.Request) {
@@ -205,9 +207,10 @@ func compile(req *definitions.Request) *Response {
 
 	for contract, item := range solcResp.Contracts {
 		respItem := ResponseItem{
-			Objectname: objectName(contract),
-			Bytecode:   strings.TrimSpace(item.Bin),
-			ABI:        strings.TrimSpace(item.Abi),
+			Objectname:      objectName(contract),
+			Bytecode:        strings.TrimSpace(item.Bin),
+			ABI:             strings.TrimSpace(item.Abi),
+			RuntimeBytecode: strings.TrimSpace(item.BinRuntime),
 		}
 		respItemArray = append(respItemArray, respItem)
 	}
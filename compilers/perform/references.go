@@ -0,0 +1,105 @@
+package perform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CodeRange is a part of the code of a contract, in bytes
+type CodeRange struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// RuntimeReferences compiles a solidity file with solc's standard json interface for the parts of
+// the runtime code of each of its contracts, by file:contract, which are only filled in once the contract
+// is deployed: immutables and the addresses of linked libraries. solc reports these offsets in
+// the standard json output only, not in the combined json the contracts are compiled with
+func RuntimeReferences(file string, optimize bool) (map[string][]CodeRange, error) {
+	input := map[string]interface{}{
+		"language": "Solidity",
+		"sources": map[string]interface{}{
+			file: map[string]interface{}{"urls": []string{file}},
+		},
+		"settings": map[string]interface{}{
+			"optimizer": map[string]interface{}{"enabled": optimize},
+			"outputSelection": map[string]interface{}{
+				"*": map[string]interface{}{
+					"*": []string{
+						"evm.deployedBytecode.immutableReferences",
+						"evm.deployedBytecode.linkReferences",
+					},
+				},
+			},
+		},
+	}
+	request, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("solc", "--standard-json", "--allow-paths", dir)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseRuntimeReferences(stdout.Bytes())
+}
+
+// parseRuntimeReferences reads the immutable and link references of the runtime code of each
+// contract from the standard json output of solc, keyed by the file solc was given or the import
+// the contract is in and the contract, as file:contract, so contracts of the same name in imported
+// files are kept apart
+func parseRuntimeReferences(output []byte) (map[string][]CodeRange, error) {
+	var result struct {
+		Errors []struct {
+			Severity         string `json:"severity"`
+			FormattedMessage string `json:"formattedMessage"`
+		} `json:"errors"`
+		Contracts map[string]map[string]struct {
+			EVM struct {
+				DeployedBytecode struct {
+					ImmutableReferences map[string][]CodeRange            `json:"immutableReferences"`
+					LinkReferences      map[string]map[string][]CodeRange `json:"linkReferences"`
+				} `json:"deployedBytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("could not read the output of solc: %v", err)
+	}
+	for _, e := range result.Errors {
+		if e.Severity == "error" {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(e.FormattedMessage))
+		}
+	}
+
+	references := make(map[string][]CodeRange)
+	for file, contracts := range result.Contracts {
+		for name, contract := range contracts {
+			code := contract.EVM.DeployedBytecode
+			ranges := []CodeRange{}
+			for _, immutables := range code.ImmutableReferences {
+				ranges = append(ranges, immutables...)
+			}
+			for _, libraries := range code.LinkReferences {
+				for _, links := range libraries {
+					ranges = append(ranges, links...)
+				}
+			}
+			references[file+":"+name] = ranges
+		}
+	}
+	return references, nil
+}
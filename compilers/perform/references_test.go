@@ -0,0 +1,37 @@
+package perform
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseRuntimeReferences(t *testing.T) {
+	output := `{"contracts":{"token.sol":{
+		"Token":{"evm":{"deployedBytecode":{
+			"immutableReferences":{"3":[{"start":10,"length":32},{"start":80,"length":32}]},
+			"linkReferences":{"math.sol":{"Math":[{"start":150,"length":20}]}}}}},
+		"Math":{"evm":{"deployedBytecode":{"immutableReferences":{},"linkReferences":{}}}}},
+		"lib/token.sol":{"Token":{"evm":{"deployedBytecode":{"immutableReferences":{},"linkReferences":{}}}}}}}`
+	references, err := parseRuntimeReferences([]byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := references["token.sol:Token"]
+	sort.Slice(token, func(i, j int) bool { return token[i].Start < token[j].Start })
+	if expected := []CodeRange{{10, 32}, {80, 32}, {150, 20}}; !reflect.DeepEqual(token, expected) {
+		t.Errorf("expected the references of Token to be %v, got %v", expected, token)
+	}
+	if math, ok := references["token.sol:Math"]; !ok || len(math) != 0 {
+		t.Errorf("expected Math to have no references, got %v", math)
+	}
+	if imported, ok := references["lib/token.sol:Token"]; !ok || len(imported) != 0 {
+		t.Errorf("expected the imported Token to be kept apart with no references, got %v", imported)
+	}
+
+	failed := `{"errors":[{"severity":"warning","formattedMessage":"unused"},
+		{"severity":"error","formattedMessage":"ParserError: expected ;"}]}`
+	if _, err := parseRuntimeReferences([]byte(failed)); err == nil || err.Error() != "ParserError: expected ;" {
+		t.Errorf("expected the error of solc, got %v", err)
+	}
+}
@@ -55,7 +55,7 @@ func TestLocalMulti(t *testing.T) {
 	util.ClearCache(config.SolcScratchPath)
 	expectedSolcResponse := definitions.BlankSolcResponse()
 
-	actualOutput, err := exec.Command("solc", "--combined-json", "bin,abi", "contractImport1.sol").Output()
+	actualOutput, err := exec.Command("solc", "--combined-json", "bin,abi,bin-runtime", "contractImport1.sol").Output()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -65,9 +65,10 @@ func TestLocalMulti(t *testing.T) {
 
 	for contract, item := range expectedSolcResponse.Contracts {
 		respItem := perform.ResponseItem{
-			Objectname: strings.TrimSpace(contract),
-			Bytecode:   strings.TrimSpace(item.Bin),
-			ABI:        strings.TrimSpace(item.Abi),
+			Objectname:      strings.TrimSpace(contract),
+			Bytecode:        strings.TrimSpace(item.Bin),
+			ABI:             strings.TrimSpace(item.Abi),
+			RuntimeBytecode: strings.TrimSpace(item.BinRuntime),
 		}
 		respItemArray = append(respItemArray, respItem)
 	}
@@ -98,7 +99,7 @@ func TestLocalSingle(t *testing.T) {
 	util.ClearCache(config.SolcScratchPath)
 	expectedSolcResponse := definitions.BlankSolcResponse()
 
-	shellCmd := exec.Command("solc", "--combined-json", "bin,abi", "simpleContract.sol")
+	shellCmd := exec.Command("solc", "--combined-json", "bin,abi,bin-runtime", "simpleContract.sol")
 	actualOutput, err := shellCmd.Output()
 	if err != nil {
 		t.Fatal(err)
@@ -110,9 +111,10 @@ func TestLocalSingle(t *testing.T) {
 
 	for contract, item := range expectedSolcResponse.Contracts {
 		respItem := perform.ResponseItem{
-			Objectname: strings.TrimSpace(contract),
-			Bytecode:   strings.TrimSpace(item.Bin),
-			ABI:        strings.TrimSpace(item.Abi),
+			Objectname:      strings.TrimSpace(contract),
+			Bytecode:        strings.TrimSpace(item.Bin),
+			ABI:             strings.TrimSpace(item.Abi),
+			RuntimeBytecode: strings.TrimSpace(item.BinRuntime),
 		}
 		respItemArray = append(respItemArray, respItem)
 	}
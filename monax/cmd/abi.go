@@ -0,0 +1,387 @@
+package commands
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	compilers "github.com/monax/bosmarmot/compilers/perform"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
+	"github.com/monax/bosmarmot/monax/pkgs/bind"
+	"github.com/monax/bosmarmot/monax/util"
+	"github.com/spf13/cobra"
+)
+
+var Abi = &cobra.Command{
+	Use:   "abi",
+	Short: "work with the abis of contracts",
+	Long: `the abi subcommand works with the abis saved by packages
+in the abi directory
+
+Where a command takes an <abi> it may be the name or address of a
+contract in the abi directory, the path of an abi file or the abi
+itself as json.`,
+	Run: func(cmd *cobra.Command, args []string) { cmd.Help() },
+}
+
+var abiList = &cobra.Command{
+	Use:   "ls",
+	Short: "list the stored abis",
+	Long: `list the stored abis
+
+Lists the contracts the abi directory holds abis for by their fully
+qualified name (file:contract) and the contracts deployed to each chain
+by their address, along with the hash of their abi.`,
+	Run: ListAbis,
+}
+
+var abiEncode = &cobra.Command{
+	Use:   "encode <abi> <function> [args...]",
+	Short: "encode the data of a call",
+	Long: `encode the data of a call
+
+Prints the selector of the function followed by the packed arguments
+as hex. Arguments are given as in packages, arrays as [a,b] and structs
+as (a,b). Use constructor as the function to encode the arguments of
+a deployment.`,
+	Run: EncodeAbi,
+}
+
+var abiDecode = &cobra.Command{
+	Use:   "decode <abi> <function> <hex>",
+	Short: "decode the return of a function",
+	Long: `decode the return of a function
+
+Prints the values the function returned, or with [--input] the
+arguments the data of a call to the function holds.`,
+	Run: DecodeAbi,
+}
+
+var abiSelector = &cobra.Command{
+	Use:   "selector <signature>",
+	Short: "print the selector of a function signature",
+	Long: `print the selector of a function signature
+
+The signature is canonicalised first, so transfer(address to, uint amount)
+has the selector of transfer(address,uint256).`,
+	Run: AbiSelector,
+}
+
+var abiTopic = &cobra.Command{
+	Use:   "topic <signature>",
+	Short: "print the topic of an event signature",
+	Long: `print the topic of an event signature
+
+The topic is the hash of the canonical signature, which the logs of the
+event have as their first topic.`,
+	Run: AbiTopic,
+}
+
+var abiDecodeLog = &cobra.Command{
+	Use:   "decode-log <abi> <topics> <data>",
+	Short: "decode a log emitted by a contract",
+	Long: `decode a log emitted by a contract
+
+The topics are given as comma separated hex, the first being the topic
+of the event. Prints the name of the event followed by its arguments.`,
+	Run: DecodeAbiLog,
+}
+
+var abiShow = &cobra.Command{
+	Use:   "show <abi>",
+	Short: "list the functions and events of an abi",
+	Long: `list the functions and events of an abi
+
+Lists the functions with their selectors and returns, and the events
+with their topics.`,
+	Run: ShowAbi,
+}
+
+var abiGenGo = &cobra.Command{
+	Use:   "gen-go <abi|contract.sol>...",
+	Short: "generate go bindings for contracts",
+	Long: `generate go bindings for contracts
+
+Writes a go package with a type for each contract, which deploys it,
+sends transactions calling its functions, queries its constant ones and
+decodes its logs through the burrow client. The contracts of solidity
+files are compiled, giving the bindings their bytecode; the bytecode of
+a contract given by its abi may be given with [--bin].
+
+Overloaded functions are bound as Name, Name1 and so on in the order
+of the abi.`,
+	Run: GenGoAbi,
+}
+
+var abiChain string
+var abiBytesEncoding string
+var abiDecodeInput bool
+var abiGenPackage string
+var abiGenOutput string
+var abiGenType string
+var abiGenBin string
+
+func buildAbiCommand() {
+	Abi.AddCommand(abiList)
+	Abi.AddCommand(abiEncode)
+	Abi.AddCommand(abiDecode)
+	Abi.AddCommand(abiSelector)
+	Abi.AddCommand(abiTopic)
+	Abi.AddCommand(abiDecodeLog)
+	Abi.AddCommand(abiShow)
+	Abi.AddCommand(abiGenGo)
+	addAbiFlags()
+}
+
+func addAbiFlags() {
+	Abi.PersistentFlags().StringVarP(&do.ABIPath, "abi-path", "", "./abi", "path to the abi directory")
+	Abi.PersistentFlags().StringVarP(&abiChain, "chain", "", "", "id of the chain to find contracts by address on, ls only lists the contracts deployed to it")
+	abiDecode.Flags().StringVarP(&abiBytesEncoding, "bytes-encoding", "", "", "encoding of bytes values, auto, string or hex (default auto: a string unless not printable UTF-8)")
+	abiDecode.Flags().BoolVarP(&abiDecodeInput, "input", "", false, "decode the data of a call to the function rather than its return")
+	abiDecodeLog.Flags().StringVarP(&abiBytesEncoding, "bytes-encoding", "", "", "encoding of bytes values, auto, string or hex (default auto: a string unless not printable UTF-8)")
+	abiGenGo.Flags().StringVarP(&abiGenPackage, "pkg", "", "bindings", "name of the go package generated")
+	abiGenGo.Flags().StringVarP(&abiGenOutput, "out", "", "", "file to write the bindings to (default stdout)")
+	abiGenGo.Flags().StringVarP(&abiGenType, "type", "", "", "name of the contract of an abi, when only one is given (default the name of the abi)")
+	abiGenGo.Flags().StringVarP(&abiGenBin, "bin", "", "", "file holding the bytecode of the contract of an abi, when only one is given")
+}
+
+func ListAbis(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(0, "eq", cmd, args))
+	store := util.NewABIStore(do.ABIPath, abiChain)
+	names, err := store.Names()
+	util.IfExit(err)
+	chains := []string{abiChain}
+	if abiChain == "" {
+		chains, err = store.Chains()
+		util.IfExit(err)
+	}
+
+	// addresses are listed with the names of the contracts with the same abi
+	contracts := make(map[string][]string)
+	for name, hash := range names {
+		contracts[hash] = append(contracts[hash], name)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTRACT\tABI")
+	for _, name := range sortedKeys(names) {
+		fmt.Fprintf(w, "%s\t%s\n", name, names[name])
+	}
+	for _, chain := range chains {
+		addresses, err := store.Addresses(chain)
+		util.IfExit(err)
+		fmt.Fprintf(w, "\nCHAIN %s\tABI\tCONTRACT\n", chain)
+		for _, address := range sortedKeys(addresses) {
+			hash := addresses[address]
+			sort.Strings(contracts[hash])
+			fmt.Fprintf(w, "%s\t%s\t%s\n", address, hash, strings.Join(contracts[hash], ", "))
+		}
+	}
+	w.Flush()
+}
+
+func EncodeAbi(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(2, "ge", cmd, args))
+	abiData, err := readAbiArgument(args[0])
+	util.IfExit(err)
+	function := args[1]
+	if function == "constructor" {
+		function = ""
+	}
+	packed, err := abi.Packer(abiData, function, args[2:]...)
+	util.IfExit(err)
+	fmt.Printf("%X\n", packed)
+}
+
+func DecodeAbi(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(3, "eq", cmd, args))
+	util.IfExit(abi.CheckBytesEncoding(abiBytesEncoding))
+	abiData, err := readAbiArgument(args[0])
+	util.IfExit(err)
+	data, err := hexArgument(args[2])
+	util.IfExit(err)
+	var vars []*definitions.Variable
+	if abiDecodeInput {
+		vars, err = abi.UnpackInput(abiData, args[1], data, abiBytesEncoding)
+	} else {
+		vars, err = abi.Unpacker(abiData, args[1], data, abiBytesEncoding)
+	}
+	util.IfExit(err)
+	printVariables(vars)
+}
+
+func AbiSelector(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	selector, err := abi.Selector(args[0])
+	util.IfExit(err)
+	fmt.Printf("%X\n", selector)
+}
+
+func AbiTopic(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	topic, err := abi.Topic(args[0])
+	util.IfExit(err)
+	fmt.Printf("%X\n", topic)
+}
+
+func DecodeAbiLog(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(3, "eq", cmd, args))
+	util.IfExit(abi.CheckBytesEncoding(abiBytesEncoding))
+	abiData, err := readAbiArgument(args[0])
+	util.IfExit(err)
+	var topics [][]byte
+	for _, topic := range strings.Split(args[1], ",") {
+		b, err := hexArgument(topic)
+		util.IfExit(err)
+		topics = append(topics, b)
+	}
+	data, err := hexArgument(args[2])
+	util.IfExit(err)
+	name, vars, err := abi.UnpackEvent(abiData, topics, data, abiBytesEncoding)
+	util.IfExit(err)
+	fmt.Println(name)
+	printVariables(vars)
+}
+
+func ShowAbi(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "eq", cmd, args))
+	abiData, err := readAbiArgument(args[0])
+	util.IfExit(err)
+	functions, err := abi.ReadFunctions(abiData)
+	util.IfExit(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FUNCTION\tSELECTOR\tRETURNS")
+	for _, fn := range functions {
+		if fn.Type == "event" {
+			continue
+		}
+		signature, err := fn.Signature()
+		util.IfExit(err)
+		// the outputs are listed as the signature of a function without a name taking them
+		returns, err := abi.Function{Inputs: fn.Outputs}.Signature()
+		util.IfExit(err)
+		var selector string
+		switch fn.Type {
+		case "constructor", "fallback":
+			signature, returns = fn.Type+signature, ""
+		default:
+			selector = fmt.Sprintf("%X", crypto.Keccak256([]byte(signature))[:4])
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", signature, selector, returns)
+	}
+	fmt.Fprintln(w, "\nEVENT\tTOPIC")
+	for _, fn := range functions {
+		if fn.Type != "event" {
+			continue
+		}
+		signature, err := fn.Signature()
+		util.IfExit(err)
+		fmt.Fprintf(w, "%s\t%X\n", signature, crypto.Keccak256([]byte(signature)))
+	}
+	w.Flush()
+}
+
+func GenGoAbi(cmd *cobra.Command, args []string) {
+	util.IfExit(ArgCheck(1, "ge", cmd, args))
+	if (abiGenType != "" || abiGenBin != "") && len(args) > 1 {
+		util.IfExit(fmt.Errorf("--type and --bin may only be given with a single abi"))
+	}
+	var contracts []bind.ContractSource
+	for _, arg := range args {
+		if filepath.Ext(arg) == ".sol" {
+			objects, err := compileContracts(arg)
+			util.IfExit(err)
+			contracts = append(contracts, objects...)
+			continue
+		}
+		abiData, err := readAbiArgument(arg)
+		util.IfExit(err)
+		contract := bind.ContractSource{Name: abiGenType, ABI: abiData}
+		if contract.Name == "" {
+			contract.Name = strings.TrimSuffix(filepath.Base(arg), filepath.Ext(arg))
+		}
+		if abiGenBin != "" {
+			bytecode, err := ioutil.ReadFile(abiGenBin)
+			util.IfExit(err)
+			contract.Bytecode = strings.TrimSpace(string(bytecode))
+		}
+		contracts = append(contracts, contract)
+	}
+
+	source, err := bind.Generate(abiGenPackage, contracts)
+	util.IfExit(err)
+	if abiGenOutput == "" {
+		fmt.Print(string(source))
+		return
+	}
+	util.IfExit(ioutil.WriteFile(abiGenOutput, source, 0644))
+}
+
+// compileContracts compiles a solidity file into the contracts it holds, with their bytecode unless
+// they are abstract
+func compileContracts(file string) ([]bind.ContractSource, error) {
+	resp, err := compilers.RequestCompile(file, false, "")
+	if err != nil {
+		return nil, err
+	} else if resp.Error != "" {
+		return nil, fmt.Errorf("could not compile %s: %s", file, resp.Error)
+	}
+	var contracts []bind.ContractSource
+	for _, object := range resp.Objects {
+		contracts = append(contracts, bind.ContractSource{
+			Name:     object.Objectname,
+			ABI:      object.ABI,
+			Bytecode: object.Bytecode,
+		})
+	}
+	return contracts, nil
+}
+
+// readAbiArgument reads an abi given as json, as the path of a file or as the name or address of a
+// contract in the abi directory
+func readAbiArgument(arg string) (string, error) {
+	if strings.HasPrefix(strings.TrimSpace(arg), "[") {
+		return arg, nil
+	}
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		b, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return util.NewABIStore(do.ABIPath, abiChain).Read(arg)
+}
+
+func hexArgument(arg string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(arg), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not hex encoded: %v", arg, err)
+	}
+	return b, nil
+}
+
+func printVariables(vars []*definitions.Variable) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range vars {
+		fmt.Fprintf(w, "%s\t%s\n", v.Name, v.Value)
+	}
+	w.Flush()
+}
+
+func sortedKeys(index map[string]string) []string {
+	var keys []string
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -54,8 +54,10 @@ func Execute() {
 func AddCommands() {
 	buildPackagesCommand()
 	buildKeysCommand()
+	buildAbiCommand()
 	BosCmd.AddCommand(Packages)
 	BosCmd.AddCommand(Keys)
+	BosCmd.AddCommand(Abi)
 	BosCmd.AddCommand(&cobra.Command{
 		Use:   "version",
 		Short: "Print Version",
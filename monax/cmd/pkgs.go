@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/monax/bosmarmot/monax/pkgs"
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
 	"github.com/monax/bosmarmot/monax/util"
 
 	"github.com/monax/bosmarmot/monax/keys"
@@ -44,7 +45,10 @@ func addPackagesFlags() {
 	// packagesDo.Flags().StringVarP(&do.ContractsPath, "contracts-path", "p", "./contracts", "path to the contracts jobs should use")
 	packagesDo.Flags().StringVarP(&do.BinPath, "bin-path", "", "./bin", "path to the bin directory jobs should use when saving binaries after the compile process")
 	packagesDo.Flags().StringVarP(&do.ABIPath, "abi-path", "", "./abi", "path to the abi directory jobs should use when saving ABIs after the compile process")
-	packagesDo.Flags().StringVarP(&do.DefaultGas, "gas", "g", "1111111111", "default gas to use; can be overridden for any single job")
+	packagesDo.Flags().StringVarP(&do.DeploymentsPath, "deployments-path", "", "./deployments", "path to the directory of per chain deployment registries (see the skip-if-unchanged field of deploy jobs)")
+	packagesDo.Flags().StringVarP(&do.DefaultGas, "gas", "g", "1111111111", "default gas to use; can be overridden for any single job. use auto to estimate the gas of calls and deploys by simulating them")
+	packagesDo.Flags().Float64VarP(&do.GasMultiplier, "gas-multiplier", "", 1.2, "safety multiplier applied to estimated (auto) gas")
+	packagesDo.Flags().StringVarP(&do.BytesEncoding, "bytes-encoding", "", "", "encoding of bytes returned by calls, queries and events: auto, string or hex. by default (auto) bytes are returned as a string unless they are not printable UTF-8; can be overridden for any single job")
 	packagesDo.Flags().StringVarP(&do.DefaultAddr, "address", "a", "", "default address to use; operates the same way as the [account] job, only before the epm file is ran")
 	packagesDo.Flags().StringVarP(&do.DefaultFee, "fee", "n", "9999", "default fee to use")
 	packagesDo.Flags().StringVarP(&do.DefaultAmount, "amount", "u", "9999", "default amount to use")
@@ -56,6 +60,7 @@ func PackagesDo(cmd *cobra.Command, args []string) {
 	if do.DefaultAddr == "" { // note that this is not strictly necessary since the addr can be set in the epm.yaml.
 		util.IfExit(fmt.Errorf("please provide the address to deploy from with --address"))
 	}
+	util.IfExit(abi.CheckBytesEncoding(do.BytesEncoding))
 
 	util.IfExit(pkgs.RunPackage(do))
 }
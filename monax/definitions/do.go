@@ -18,6 +18,16 @@ type Do struct {
 	DefaultFee    string `mapstructure:"," json:"," yaml:"," toml:","`
 	DefaultAmount string `mapstructure:"," json:"," yaml:"," toml:","`
 
+	// safety multiplier for auto gas
+	GasMultiplier float64 `mapstructure:"," json:"," yaml:"," toml:","`
+	// where the per chain registries of deployed contracts are kept
+	DeploymentsPath string `mapstructure:"," json:"," yaml:"," toml:","`
+	// id of the chain the package is run against, the abis of deployed contracts are kept per chain
+	ChainID string `mapstructure:"," json:"," yaml:"," toml:","`
+	// how bytes are returned by jobs which do not say, auto, string or hex (by default auto, a string
+	// unless the bytes are not printable UTF-8)
+	BytesEncoding string `mapstructure:"," json:"," yaml:"," toml:","`
+
 	// for [monax pkgs do]
 	YAMLPath      string   `mapstructure:"," json:"," yaml:"," toml:","`
 	ContractsPath string   `mapstructure:"," json:"," yaml:"," toml:","`
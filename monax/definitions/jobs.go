@@ -127,7 +127,10 @@ type Deploy struct {
 	// relative to the contracts path established via the --contracts-path flag or the $EPM_CONTRACTS_PATH
 	// environment variable. If contract has a "bin" file extension then it will not be sent to the
 	// compilers but rather will just be sent to the chain. Note, if you use a "call" job after deploying
-	// a binary contract then you will be **required** to utilize an abi field in the call job.
+	// a binary contract then you will be **required** to utilize an abi field in the call job. If contract
+	// has a "json" file extension it is read as solc combined-json or standard-json output, or as a
+	// truffle artifact, and the bytecode and abi of the instance are taken from it. The abi of a contract of
+	// solc output is saved under the file:contract it is keyed by there.
 	Contract string `mapstructure:"contract" json:"contract" yaml:"contract" toml:"contract"`
 	// (Optional) the name of contract to instantiate (it has to be one of the contracts present)
 	// in the file defined in Contract above.
@@ -137,16 +140,21 @@ type Deploy struct {
 	// the name of the file (or the last one deployed if there are no matching names; not the "last"
 	// one deployed" strategy is non-deterministic and should not be used).
 	Instance string `mapstructure:"instance" json:"instance" yaml:"instance" toml:"instance"`
-	// (Optional) list of Name:Address separated by commas of libraries (see solc --help)
+	// (Optional) list of Name:Address separated by commas of libraries (see solc --help). Libraries
+	// which are not listed are linked to the address given in the libraries of the package, or to the
+	// library as deployed by an earlier job, whatever its instance, or else deployed from the same source
+	// before the contract
 	Libraries string `mapstructure:"libraries" json:"libraries" yaml:"libraries" toml:"libraries"`
-	// (Optional) TODO: additional arguments to send along with the contract code
+	// (Optional) constructor arguments, as a list or as a map of the names of the parameters to
+	// their values, with maps for structs and lists for arrays
 	Data interface{} `mapstructure:"data" json:"data" yaml:"data" toml:"data"`
 	// (Optional) amount of tokens to send to the contract which will (after deployment) reside in the
 	// contract's account
 	Amount string `mapstructure:"amount" json:"amount" yaml:"amount" toml:"amount"`
 	// (Optional) validators' fee
 	Fee string `mapstructure:"fee" json:"fee" yaml:"fee" toml:"fee"`
-	// (Optional) amount of gas which should be sent along with the contract deployment transaction
+	// (Optional) amount of gas which should be sent along with the contract deployment transaction, or
+	// auto to estimate it by simulating the deployment (see --gas-multiplier)
 	Gas string `mapstructure:"gas" json:"gas" yaml:"gas" toml:"gas"`
 	// (Optional) after compiling the contract save the binary in filename.bin in same directory
 	// where the *.sol or *.se file is located. This will speed up subsequent installs
@@ -154,7 +162,16 @@ type Deploy struct {
 	// (Optional, advanced only) nonce to use when monax-keys signs the transaction (do not use unless you
 	// know what you're doing)
 	Nonce string `mapstructure:"nonce" json:"nonce" yaml:"nonce" toml:"nonce"`
-	// (Optional) todo
+	// (Optional) set to "revert" when the transaction is expected to throw. The job then passes only
+	// if the transaction throws and fails if it succeeds. Useful for testing the negative paths of contracts
+	Expect string `mapstructure:"expect" json:"expect" yaml:"expect" toml:"expect"`
+	// (Optional) when expecting a revert, a substring which the revert reason must contain
+	ExpectReason string `mapstructure:"expect-reason" json:"expect-reason" yaml:"expect-reason" toml:"expect-reason"`
+	// (Optional) reuse the address this job deployed the contract to on the chain before (as recorded
+	// in the deployments path) when the bytecode, abi and constructor arguments are unchanged and the
+	// contract is still on the chain
+	SkipIfUnchanged bool `mapstructure:"skip-if-unchanged" json:"skip-if-unchanged" yaml:"skip-if-unchanged" toml:"skip-if-unchanged"`
+	// (Optional) the events emitted while deploying, addressable as $job.events.<name>[<n>].<argument>
 	Variables []*Variable
 }
 
@@ -164,16 +181,25 @@ type Call struct {
 	Source string `mapstructure:"source" json:"source" yaml:"source" toml:"source"`
 	// (Required) address of the contract which should be called
 	Destination string `mapstructure:"destination" json:"destination" yaml:"destination" toml:"destination"`
-	// (Required unless testing fallback function) function inside the contract to be called
+	// (Required unless testing fallback function or giving raw-data) function inside the contract to be
+	// called, () for the fallback function. Overloaded functions are given by their signature, such as
+	// transfer(address,uint256), unless the arguments are given by name
 	Function string `mapstructure:"function" json:"function" yaml:"function" toml:"function"`
 	// (Optional) data which should be called. will use the monax-abi tooling under the hood to formalize the
-	// transaction
+	// transaction. Either a list of arguments or a map of the names of the parameters to their values,
+	// with maps for structs and lists for arrays. Arrays and structs given as a string are written as
+	// [a,b] and (a,b), quoting strings within them which hold commas or brackets, as in ["a, b",c]
 	Data interface{} `mapstructure:"data" json:"data" yaml:"data" toml:"data"`
+	// (Optional) hex encoded call data sent as is instead of a function and its data, such as for
+	// fallback functions taking a payload, contracts without an abi or native contracts. The result
+	// of the job is then the hex encoded return
+	RawData string `mapstructure:"raw-data" json:"raw-data" yaml:"raw-data" toml:"raw-data"`
 	// (Optional) amount of tokens to send to the contract
 	Amount string `mapstructure:"amount" json:"amount" yaml:"amount" toml:"amount"`
 	// (Optional) validators' fee
 	Fee string `mapstructure:"fee" json:"fee" yaml:"fee" toml:"fee"`
-	// (Optional) amount of gas which should be sent along with the call transaction
+	// (Optional) amount of gas which should be sent along with the call transaction, or auto to
+	// estimate it by simulating the call (see --gas-multiplier)
 	Gas string `mapstructure:"gas" json:"gas" yaml:"gas" toml:"gas"`
 	// (Optional, advanced only) nonce to use when monax-keys signs the transaction (do not use unless you
 	// know what you're doing)
@@ -187,27 +213,121 @@ type Call struct {
 	// return from the call job as the result of the call job then select "tx" on the save
 	// variable. Anything other than "tx" in this field will use the default.
 	Save string `mapstructure:"save" json:"save" yaml:"save" toml:"save"`
-	// (Optional) the call job's returned variables
+	// (Optional) set to "revert" when the transaction is expected to throw. The job then passes only
+	// if the transaction throws and fails if it succeeds. Useful for testing the negative paths of contracts
+	Expect string `mapstructure:"expect" json:"expect" yaml:"expect" toml:"expect"`
+	// (Optional) when expecting a revert, a substring which the revert reason must contain
+	ExpectReason string `mapstructure:"expect-reason" json:"expect-reason" yaml:"expect-reason" toml:"expect-reason"`
+	// (Optional) encoding of the bytes and fixed bytes returned, auto, string or hex. By default (auto)
+	// bytes are returned as a string unless they are not printable UTF-8 (see --bytes-encoding)
+	BytesEncoding string `mapstructure:"bytes-encoding" json:"bytes-encoding" yaml:"bytes-encoding" toml:"bytes-encoding"`
+	// (Optional) the call job's returned variables, along with the events the call emitted which are
+	// addressable as $job.events.<name>[<n>].<argument>
 	Variables []*Variable
 }
 
+type Proxy struct {
+	// (Optional, if account job or global account set) address of the account from which to send (the
+	// public key for the account must be available to monax-keys). This account is the admin of the
+	// proxy and the only account which can upgrade it
+	Source string `mapstructure:"source" json:"source" yaml:"source" toml:"source"`
+	// (Required) the filepath to the implementation contract, as for the deploy job
+	Contract string `mapstructure:"contract" json:"contract" yaml:"contract" toml:"contract"`
+	// (Optional) the name of the implementation contract in the file defined in Contract above. When
+	// none is provided, the contract with the same name as the file is deployed
+	Instance string `mapstructure:"instance" json:"instance" yaml:"instance" toml:"instance"`
+	// (Optional) list of Name:Address separated by commas of libraries, as for the deploy job
+	Libraries string `mapstructure:"libraries" json:"libraries" yaml:"libraries" toml:"libraries"`
+	// (Not supported) constructor arguments of the implementation contract, which are rejected since the
+	// constructor runs against the storage of the implementation, not of the proxy. Initialise the proxy
+	// with a call job to it instead
+	Data interface{} `mapstructure:"data" json:"data" yaml:"data" toml:"data"`
+	// (Optional) validators' fee
+	Fee string `mapstructure:"fee" json:"fee" yaml:"fee" toml:"fee"`
+	// (Optional) amount of gas which should be sent along with each transaction, or auto to estimate it
+	Gas string `mapstructure:"gas" json:"gas" yaml:"gas" toml:"gas"`
+	// (Optional) the address of the implementation as $job.implementation, along with the variables of
+	// deploying it
+	Variables []*Variable
+}
+
+type Upgrade struct {
+	// (Optional, if account job or global account set) address of the account from which to send (the
+	// public key for the account must be available to monax-keys). Must be the admin of the proxy
+	Source string `mapstructure:"source" json:"source" yaml:"source" toml:"source"`
+	// (Required) address of the proxy which should be upgraded
+	Proxy string `mapstructure:"proxy" json:"proxy" yaml:"proxy" toml:"proxy"`
+	// (Required) the filepath to the new implementation contract, as for the deploy job
+	Contract string `mapstructure:"contract" json:"contract" yaml:"contract" toml:"contract"`
+	// (Optional) the name of the new implementation contract in the file defined in Contract above
+	Instance string `mapstructure:"instance" json:"instance" yaml:"instance" toml:"instance"`
+	// (Optional) list of Name:Address separated by commas of libraries, as for the deploy job
+	Libraries string `mapstructure:"libraries" json:"libraries" yaml:"libraries" toml:"libraries"`
+	// (Not supported) constructor arguments of the new implementation contract, which are rejected as
+	// for the proxy job
+	Data interface{} `mapstructure:"data" json:"data" yaml:"data" toml:"data"`
+	// (Optional) validators' fee
+	Fee string `mapstructure:"fee" json:"fee" yaml:"fee" toml:"fee"`
+	// (Optional) amount of gas which should be sent along with each transaction, or auto to estimate it
+	Gas string `mapstructure:"gas" json:"gas" yaml:"gas" toml:"gas"`
+	// (Optional) the address of the new implementation as $job.implementation, along with the
+	// variables of deploying it
+	Variables []*Variable
+}
+
+type Verify struct {
+	// (Required) the filepath to the source of the contract, as for the deploy job
+	Contract string `mapstructure:"contract" json:"contract" yaml:"contract" toml:"contract"`
+	// (Optional) the name of the contract in the source to verify. When none is provided, the contract
+	// with the same name as the file is verified
+	Instance string `mapstructure:"instance" json:"instance" yaml:"instance" toml:"instance"`
+	// (Required) address of the deployed contract whose code should match the compiled contract
+	Address string `mapstructure:"address" json:"address" yaml:"address" toml:"address"`
+	// (Optional) list of Name:Address separated by commas of libraries the contract was linked to.
+	// The code where libraries which are not listed are linked is not compared
+	Libraries string `mapstructure:"libraries" json:"libraries" yaml:"libraries" toml:"libraries"`
+}
+
 // ------------------------------------------------------------------------
 // State Jobs
 // ------------------------------------------------------------------------
 
+// Dumps every account of the chain (with its balance, sequence, permissions, code and storage) and
+// every name registry entry to a versioned JSON file. The result of the job is the path of the file
 type DumpState struct {
-	WithValidators bool   `mapstructure:"include-validators" json:"include-validators" yaml:"include-validators" toml:"include-validators"`
-	ToIPFS         bool   `mapstructure:"to-ipfs" json:"to-ipfs" yaml:"to-ipfs" toml:"to-ipfs"`
-	ToFile         bool   `mapstructure:"to-file" json:"to-file" yaml:"to-file" toml:"to-file"`
-	IPFSHost       string `mapstructure:"ipfs-host" json:"ipfs-host" yaml:"ipfs-host" toml:"ipfs-host"`
-	FilePath       string `mapstructure:"file" json:"file" yaml:"file" toml:"file"`
+	// (Optional) also dump the bonded and unbonding validators
+	WithValidators bool `mapstructure:"include-validators" json:"include-validators" yaml:"include-validators" toml:"include-validators"`
+	// (Not supported) dump the state to IPFS
+	ToIPFS bool `mapstructure:"to-ipfs" json:"to-ipfs" yaml:"to-ipfs" toml:"to-ipfs"`
+	// (Optional) dump the state to a file, which is what is done in any case
+	ToFile   bool   `mapstructure:"to-file" json:"to-file" yaml:"to-file" toml:"to-file"`
+	IPFSHost string `mapstructure:"ipfs-host" json:"ipfs-host" yaml:"ipfs-host" toml:"ipfs-host"`
+	// (Optional) the file to write, by default state-<chain id>-<block height>.json
+	FilePath string `mapstructure:"file" json:"file" yaml:"file" toml:"file"`
 }
 
+// Restores a state dumped by a dump-state job, either as a genesis for a new chain or by replaying it
+// as transactions on the chain the package is run against. What cannot be restored is logged and
+// the result of the job is how many such things there are
 type RestoreState struct {
-	FromIPFS bool   `mapstructure:"from-ipfs" json:"from-ipfs" yaml:"from-ipfs" toml:"from-ipfs"`
+	// (Not supported) restore the state from IPFS
+	FromIPFS bool `mapstructure:"from-ipfs" json:"from-ipfs" yaml:"from-ipfs" toml:"from-ipfs"`
+	// (Optional) restore the state from a file, which is what is done in any case
 	FromFile bool   `mapstructure:"from-file" json:"from-file" yaml:"from-file" toml:"from-file"`
 	IPFSHost string `mapstructure:"ipfs-host" json:"ipfs-host" yaml:"ipfs-host" toml:"ipfs-host"`
+	// (Required) the file written by the dump-state job
 	FilePath string `mapstructure:"file" json:"file" yaml:"file" toml:"file"`
+	// (Optional) genesis writes a burrow genesis holding the accounts, their permissions and the
+	// validators. replay sends the balances, sets the permissions, registers the names and deploys
+	// the contracts, with their storage, at new addresses. Defaults to genesis
+	Mode string `mapstructure:"mode" json:"mode" yaml:"mode" toml:"mode"`
+	// (Optional, genesis only) the file to write the genesis to, by default genesis.json
+	Genesis string `mapstructure:"genesis" json:"genesis" yaml:"genesis" toml:"genesis"`
+	// (Optional, genesis only) the name of the new chain, by default the id of the dumped chain
+	ChainName string `mapstructure:"chain-name" json:"chain-name" yaml:"chain-name" toml:"chain-name"`
+	// (Optional, replay only) address of the account sending the transactions, which needs the funds
+	// and the permissions to do so (the public key for the account must be available to monax-keys)
+	Source string `mapstructure:"source" json:"source" yaml:"source" toml:"source"`
 }
 
 // ------------------------------------------------------------------------
@@ -221,16 +341,21 @@ type QueryContract struct {
 	Source string `mapstructure:"source" json:"source" yaml:"source" toml:"source"`
 	// (Required) address of the contract which should be called
 	Destination string `mapstructure:"destination" json:"destination" yaml:"destination" toml:"destination"`
-	// (Required) data which should be called. will use the monax-abi tooling under the hood to formalize the
-	// transaction. QueryContract will usually be used with "accessor" functions in contracts
+	// (Required unless giving raw-data) data which should be called. will use the monax-abi tooling under the
+	// hood to formalize the transaction. QueryContract will usually be used with "accessor" functions in contracts.
+	// Overloaded functions are given by their signature as for the call job
 	Function string `mapstructure:"function" json:"function" yaml:"function" toml:"function"`
 	// (Optional) data to be used in the function arguments. Will use the monax-abi tooling under the hood to formalize the
-	// transaction.
+	// transaction. Given as for the call job.
 	Data interface{} `mapstructure:"data" json:"data" yaml:"data" toml:"data"`
+	// (Optional) hex encoded call data sent as is, as for the call job
+	RawData string `mapstructure:"raw-data" json:"raw-data" yaml:"raw-data" toml:"raw-data"`
 	// (Optional) location of the abi file to use (can be relative path or in abi path)
 	// deployed contracts save ABI artifacts in the abi folder as *both* the name of the contract
 	// and the address where the contract was deployed to
 	ABI string `mapstructure:"abi" json:"abi" yaml:"abi" toml:"abi"`
+	// (Optional) encoding of the bytes returned, as for the call job
+	BytesEncoding string `mapstructure:"bytes-encoding" json:"bytes-encoding" yaml:"bytes-encoding" toml:"bytes-encoding"`
 
 	Variables []*Variable
 }
@@ -257,18 +382,75 @@ type QueryVals struct {
 	Field string `mapstructure:"field" json:"field" yaml:"field" toml:"field"`
 }
 
+type QueryStorage struct {
+	// (Required) address of the contract whose storage should be read
+	Account string `mapstructure:"account" json:"account" yaml:"account" toml:"account"`
+	// (Required) the slot to read, in decimal or 0x prefixed hex. Solidity lays state variables out in
+	// slots from 0 in the order they are declared, packing those smaller than a word together
+	Slot string `mapstructure:"slot" json:"slot" yaml:"slot" toml:"slot"`
+	// (Optional) key of an entry of the mapping at the slot, which is read from keccak256(key . slot)
+	Key string `mapstructure:"key" json:"key" yaml:"key" toml:"key"`
+	// (Optional) abi type of the key, by default uint256. String and bytes keys are hashed unpadded
+	KeyType string `mapstructure:"key-type" json:"key-type" yaml:"key-type" toml:"key-type"`
+	// (Optional) abi type the value is decoded as, which must fit in a word. Without one the word is
+	// returned as hex
+	Type string `mapstructure:"type" json:"type" yaml:"type" toml:"type"`
+	// (Optional) for values packed into a word with others, the number of bytes of the word below the value
+	Offset int `mapstructure:"offset" json:"offset" yaml:"offset" toml:"offset"`
+	// (Optional) encoding of bytes values, as for the call job
+	BytesEncoding string `mapstructure:"bytes-encoding" json:"bytes-encoding" yaml:"bytes-encoding" toml:"bytes-encoding"`
+}
+
+type QueryTx struct {
+	// (Required) hash of the transaction, as saved by a call job with save: tx. The job gives the
+	// status (ok or reverted), height, gas.used, return, exception, contract and events. The node
+	// keeps no receipts and cannot look transactions up by hash, so only transactions sent earlier in
+	// the same run of the package can be queried, the job fails for any other
+	Hash string `mapstructure:"hash" json:"hash" yaml:"hash" toml:"hash"`
+	// (Optional) location of the abi the events are decoded with, by default that of the contract
+	// called or created
+	ABI string `mapstructure:"abi" json:"abi" yaml:"abi" toml:"abi"`
+	// (Optional) encoding of bytes in events, as for the call job
+	BytesEncoding string `mapstructure:"bytes-encoding" json:"bytes-encoding" yaml:"bytes-encoding" toml:"bytes-encoding"`
+}
+
+type QueryBlock struct {
+	// (Required) height of the block, such as $block for the latest one. The job gives the hash,
+	// height, time and number of transactions (txs) of the block. The node does not record the
+	// proposer of a block, so it is not available
+	Height string `mapstructure:"height" json:"height" yaml:"height" toml:"height"`
+}
+
 type Assert struct {
 	// (Required) key which should be used for the assertion. This is usually known as the "expected"
 	// value in most testing suites
 	Key string `mapstructure:"key" json:"key" yaml:"key" toml:"key"`
-	// (Required) must be of the set ["eq", "ne", "ge", "gt", "le", "lt", "==", "!=", ">=", ">", "<=", "<"]
-	// establishes the relation to be tested by the assertion. If a strings key:value pair is being used
-	// only the equals or not-equals relations may be used as the key:value will try to be converted to
-	// ints for the remainder of the relations. if strings are passed to them then `monax pkgs do` will return an
-	// error
+	// (Required) must be of the set ["eq", "ne", "ge", "gt", "le", "lt", "==", "!=", ">=", ">", "<=", "<",
+	// "matches", "contains", "not-contains", "in", "approx", "len-eq", "set-eq", "empty", "not-empty"]
+	// establishes the relation to be tested by the assertion, which reads as "key relation val". If a strings
+	// key:value pair is being used only the equals or not-equals relations may be used as the key:value will
+	// try to be converted to numbers for the ordering relations. if strings are passed to them then `monax pkgs do`
+	// will return an error. Numbers are read as integer arguments are, as arbitrarily large decimals with
+	// underscores between digits or an exponent ("1_000", "1e18") or 0x prefixed hex, but may also have a
+	// fractional part such as "1.50". When both key and value are numbers the equals and not-equals relations compare
+	// them numerically, as the ordering relations do, so "0010" equals "10" and "0xff" equals "255".
+	// The remaining relations are:
+	//   matches: the key matches the regular expression in val
+	//   contains / not-contains: the key array has (or has not) val as an element, or for strings val is
+	//     (or is not) a substring of the key
+	//   in: the key is an element of the val array, given as "[a,b,c]" or "a,b,c"
+	//   approx: the key is within tolerance of val (both must be numbers); see Tolerance
+	//   len-eq: the key array has val elements, or the key string has val characters
+	//   set-eq: the key and val arrays hold the same elements, ignoring order
+	//   empty / not-empty: the key is (or is not) an empty string or empty array; val is ignored
+	//   emitted / not-emitted: the events of a call or deploy job, given as the key $job.events, include
+	//     (or do not include) the event named in val
 	Relation string `mapstructure:"relation" json:"relation" yaml:"relation" toml:"relation"`
 	// (Required) value which should be used for the assertion. This is usually known as the "given"
 	// value in most testing suites. Generally it will be a variable expansion from one of the query
 	// jobs.
 	Value string `mapstructure:"val" json:"val" yaml:"val" toml:"val"`
+	// (Required for approx relation) maximum allowed difference between key and val. Give a number for an
+	// absolute tolerance or a percentage such as "1%" for a tolerance relative to val
+	Tolerance string `mapstructure:"tolerance" json:"tolerance" yaml:"tolerance" toml:"tolerance"`
 }
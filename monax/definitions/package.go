@@ -30,9 +30,15 @@ type Job struct {
 	Rebond *Rebond `mapstructure:"rebond" json:"rebond" yaml:"rebond" toml:"rebond"`
 	// Sends a transaction to a contract. Will utilize monax-abi under the hood to perform all of the heavy lifting
 	Call *Call `mapstructure:"call" json:"call" yaml:"call" toml:"call"`
-	// Wrapper for mintdump dump. WIP
+	// Deploys a contract behind a proxy which forwards calls to it, so it can be upgraded later
+	Proxy *Proxy `mapstructure:"proxy" json:"proxy" yaml:"proxy" toml:"proxy"`
+	// Deploys a new implementation of a contract deployed behind a proxy and points the proxy to it
+	Upgrade *Upgrade `mapstructure:"upgrade" json:"upgrade" yaml:"upgrade" toml:"upgrade"`
+	// Checks that the code of a deployed contract matches the contract compiled from source
+	Verify *Verify `mapstructure:"verify" json:"verify" yaml:"verify" toml:"verify"`
+	// Dumps the accounts, names and optionally validators of the chain to a file
 	DumpState *DumpState `mapstructure:"dump-state" json:"dump-state" yaml:"dump-state" toml:"dump-state"`
-	// Wrapper for mintdum restore. WIP
+	// Restores a dumped state as a genesis or by replaying it on the chain
 	RestoreState *RestoreState `mapstructure:"restore-state" json:"restore-state" yaml:"restore-state" toml:"restore-state"`
 	// Sends a "simulated call" to a contract. Predominantly used for accessor functions ("Getters" within contracts)
 	QueryContract *QueryContract `mapstructure:"query-contract" json:"query-contract" yaml:"query-contract" toml:"query-contract"`
@@ -42,15 +48,26 @@ type Job struct {
 	QueryName *QueryName `mapstructure:"query-name" json:"query-name" yaml:"query-name" toml:"query-name"`
 	// Queries information about the validator set
 	QueryVals *QueryVals `mapstructure:"query-vals" json:"query-vals" yaml:"query-vals" toml:"query-vals"`
+	// Reads a word of the storage of a contract
+	QueryStorage *QueryStorage `mapstructure:"query-storage" json:"query-storage" yaml:"query-storage" toml:"query-storage"`
+	// Queries the outcome of a transaction sent earlier in the same run
+	QueryTx *QueryTx `mapstructure:"query-tx" json:"query-tx" yaml:"query-tx" toml:"query-tx"`
+	// Queries a block, giving its hash, height, time and number of transactions but not its proposer,
+	// which the node does not record
+	QueryBlock *QueryBlock `mapstructure:"query-block" json:"query-block" yaml:"query-block" toml:"query-block"`
 	// Makes and assertion (useful for testing purposes)
 	Assert *Assert `mapstructure:"assert" json:"assert" yaml:"assert" toml:"assert"`
 }
 
 type Package struct {
 	// from epm
-	Account   string
-	Jobs      []*Job
+	Account string
+	Jobs    []*Job
+	// library name to address, used to link libraries the deploy jobs do not list
 	Libraries map[string]string
+	// unit name to number of decimals, so amounts and integer arguments can be given as 5 token with
+	// token: 18. Names are not case sensitive
+	Units map[string]int
 }
 
 func BlankPackage() *Package {
@@ -0,0 +1,428 @@
+package abi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// PreProcessInputData returns the function and the arguments to call it with from the data of a
+// job. The data is a list of arguments, a map of the names of the parameters to arguments, which is
+// ordered and checked against the inputs of the function in the abi at abiLocation, or the
+// deprecated string of the function followed by its arguments separated by spaces
+func PreProcessInputData(abiLocation, function string, data interface{}, do *definitions.Do, constructor bool) (string, []string, error) {
+	var callDataArray []string
+	var callArray []string
+	if function == "" && !constructor {
+		if kind := reflect.TypeOf(data).Kind(); kind == reflect.Slice || kind == reflect.Map {
+			return "", []string{""}, fmt.Errorf("Incorrect formatting of epm.yaml. Please update it to include a function field.")
+		}
+		function = strings.Split(data.(string), " ")[0]
+		callArray = strings.Split(data.(string), " ")[1:]
+		for _, val := range callArray {
+			output, _ := util.PreProcess(val, do)
+			callDataArray = append(callDataArray, output)
+		}
+	} else if data != nil {
+		if values, ok := stringMap(data); ok {
+			return namedInputData(abiLocation, function, values, do, constructor)
+		}
+		if reflect.TypeOf(data).Kind() != reflect.Slice {
+			if constructor {
+				log.Warn("Deprecation Warning: Your deploy job is currently using a soon to be deprecated way of declaring constructor values. Please remember to update your run file to store them as a array rather than a string. See documentation for further details.")
+				callArray = strings.Split(data.(string), " ")
+				for _, val := range callArray {
+					output, _ := util.PreProcess(val, do)
+					callDataArray = append(callDataArray, output)
+				}
+				return function, callDataArray, nil
+			} else {
+				return "", make([]string, 0), fmt.Errorf("Incorrect formatting of epm.yaml file. Please update it to include a function field.")
+			}
+		}
+		val := reflect.ValueOf(data)
+		values := make([]interface{}, val.Len())
+		for i := range values {
+			values[i] = val.Index(i).Interface()
+		}
+		var err error
+		if function, callDataArray, err = positionalInputData(abiLocation, function, values, do, constructor); err != nil {
+			return "", nil, err
+		}
+		log.WithField("=>", callDataArray).Debug("Arguments")
+	}
+	return function, callDataArray, nil
+}
+
+// functionOverloads returns each overload of a function given by name, the function given by
+// signature or the constructor, found as the codec finds the function it packs
+func functionOverloads(abiData, function string, constructor bool) ([]Function, error) {
+	functions, err := ReadFunctions(abiData)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the abi: %v", err)
+	}
+	if constructor {
+		for _, fn := range functions {
+			if fn.Type == "constructor" {
+				return []Function{fn}, nil
+			}
+		}
+		// a contract without a constructor takes no arguments
+		return []Function{{Type: "constructor"}}, nil
+	}
+	overloads, err := findFunctions(functions, function)
+	if err != nil {
+		return nil, err
+	}
+	if len(overloads) == 0 {
+		return nil, fmt.Errorf("function %s is not in the abi", function)
+	}
+	return overloads, nil
+}
+
+// selectedFunction is the function the arguments are packed for, the signature of the overload
+// picked when the function is overloaded
+func selectedFunction(function string, overloads []Function, picked Function) (string, error) {
+	if len(overloads) > 1 {
+		return picked.Signature()
+	}
+	return function, nil
+}
+
+// namedInputData orders the arguments given as a map of parameter names to values by the inputs of
+// the function in the abi, picking the overload taking parameters of those names
+func namedInputData(abiLocation, function string, values map[string]interface{}, do *definitions.Do,
+	constructor bool) (string, []string, error) {
+	abiData, err := util.ReadAbi(do, abiLocation)
+	if err != nil {
+		return "", nil, err
+	}
+	overloads, err := functionOverloads(abiData, function, constructor)
+	if err != nil {
+		return "", nil, err
+	}
+	picked := overloads[0]
+	if len(overloads) > 1 {
+		var found bool
+		for _, overload := range overloads {
+			if sameNames(overload.Inputs, values) {
+				picked, found = overload, true
+				break
+			}
+		}
+		if !found {
+			return "", nil, fmt.Errorf("no overload of %s takes the parameters %s", function,
+				strings.Join(sortedNames(values), ", "))
+		}
+	}
+	name := function
+	if constructor {
+		name = "constructor"
+	}
+	args, err := namedArguments(picked.Inputs, values, "", false, do)
+	if err != nil {
+		return "", nil, fmt.Errorf("arguments of %s do not match the abi: %v", name, err)
+	}
+	if function, err = selectedFunction(function, overloads, picked); err != nil {
+		return "", nil, err
+	}
+	return function, args, nil
+}
+
+// positionalInputData formats the arguments given as a list. The abi is only read when a struct
+// is given as a map, to order its fields, a value is given in a unit, to check it is an integer,
+// or a string within a list needs quoting, to tell strings from nested lists. The overload taking
+// as many arguments is then picked, several of them being ambiguous
+func positionalInputData(abiLocation, function string, values []interface{}, do *definitions.Do,
+	constructor bool) (string, []string, error) {
+	inputs := make([]*Argument, len(values))
+	if containsMap(values) || containsUnit(values, do) || containsQuotable(values, false, do) {
+		abiData, err := util.ReadAbi(do, abiLocation)
+		if err != nil {
+			return "", nil, err
+		}
+		overloads, err := functionOverloads(abiData, function, constructor)
+		if err != nil {
+			return "", nil, err
+		}
+		var matching []Function
+		for _, overload := range overloads {
+			if len(overload.Inputs) == len(values) {
+				matching = append(matching, overload)
+			}
+		}
+		switch len(matching) {
+		case 0:
+			name := function
+			if constructor {
+				name = "constructor"
+			}
+			return "", nil, fmt.Errorf("no overload of %s takes %d arguments", name, len(values))
+		case 1:
+			for i := range matching[0].Inputs {
+				inputs[i] = &matching[0].Inputs[i]
+			}
+			if function, err = selectedFunction(function, overloads, matching[0]); err != nil {
+				return "", nil, err
+			}
+		default:
+			signatures := make([]string, len(matching))
+			for i, overload := range matching {
+				if signatures[i], err = overload.Signature(); err != nil {
+					return "", nil, err
+				}
+			}
+			return "", nil, fmt.Errorf("function %s is overloaded, give one of the signatures %s", function,
+				strings.Join(signatures, ", "))
+		}
+	}
+	args := make([]string, len(values))
+	for i, value := range values {
+		name := strconv.Itoa(i)
+		if inputs[i] != nil && inputs[i].Name != "" {
+			name = inputs[i].Name
+		}
+		var err error
+		if args[i], err = formatArgument(inputs[i], value, name, do); err != nil {
+			return "", nil, err
+		}
+	}
+	return function, args, nil
+}
+
+// namedArguments orders the values by the inputs they are named after and formats them, naming the
+// inputs without a value and the values without an input in the error. The fields of a struct are
+// nested, so strings among them are quoted as needed
+func namedArguments(inputs []Argument, values map[string]interface{}, path string, nested bool,
+	do *definitions.Do) ([]string, error) {
+	var missing, extra []string
+	names := make(map[string]bool)
+	for i, input := range inputs {
+		if input.Name == "" {
+			missing = append(missing, fmt.Sprintf("%s#%d (unnamed)", path, i))
+			continue
+		}
+		names[input.Name] = true
+		if _, ok := values[input.Name]; !ok {
+			missing = append(missing, path+input.Name)
+		}
+	}
+	for _, name := range sortedNames(values) {
+		if !names[name] {
+			extra = append(extra, path+name)
+		}
+	}
+	if len(missing) > 0 || len(extra) > 0 {
+		var problems []string
+		if len(missing) > 0 {
+			problems = append(problems, "missing "+strings.Join(missing, ", "))
+		}
+		if len(extra) > 0 {
+			problems = append(problems, "unknown "+strings.Join(extra, ", "))
+		}
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	args := make([]string, len(inputs))
+	for i := range inputs {
+		var err error
+		if nested {
+			args[i], err = formatElement(&inputs[i], values[inputs[i].Name], path+inputs[i].Name, do)
+		} else {
+			args[i], err = formatArgument(&inputs[i], values[inputs[i].Name], path+inputs[i].Name, do)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// maxExactFloat is the largest float below which every integer is held exactly
+const maxExactFloat = 1 << 53
+
+// formatArgument turns a value from the package into the string form the abi packer takes: lists
+// as [a,b], structs as (a,b) with their fields in the order of the abi and integers given in a unit
+// of the package, such as 5 token, as the integer. The input is nil when the abi was not read, in
+// which case the value is not checked against it
+func formatArgument(input *Argument, value interface{}, path string, do *definitions.Do) (string, error) {
+	if values, ok := stringMap(value); ok {
+		if input == nil || input.Type != "tuple" {
+			return "", fmt.Errorf("%s is given as a map but is not a struct", path)
+		}
+		args, err := namedArguments(input.Components, values, path+".", true, do)
+		if err != nil {
+			return "", err
+		}
+		return "(" + strings.Join(args, ",") + ")", nil
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		var element *Argument
+		if input != nil {
+			i := strings.LastIndex(input.Type, "[")
+			if i < 0 || !strings.HasSuffix(input.Type, "]") {
+				return "", fmt.Errorf("%s is given as a list but is of type %s", path, input.Type)
+			}
+			element = &Argument{Name: input.Name, Type: input.Type[:i], Components: input.Components}
+		}
+		args := make([]string, len(v))
+		for i, value := range v {
+			var err error
+			if args[i], err = formatElement(element, value, fmt.Sprintf("%s[%d]", path, i), do); err != nil {
+				return "", err
+			}
+		}
+		return "[" + strings.Join(args, ",") + "]", nil
+	case string:
+		arg, err := util.PreProcess(v, do)
+		if err != nil || input == nil || !strings.HasPrefix(input.Type, "int") && !strings.HasPrefix(input.Type, "uint") {
+			return arg, err
+		}
+		return withUnit(arg, do)
+	case float64:
+		// yaml reads numbers which do not fit in an int64 as floats, rounding them
+		if v != math.Trunc(v) || math.Abs(v) > maxExactFloat {
+			return "", fmt.Errorf("%s is given as %v, which is not an integer the yaml can hold exactly, "+
+				"give it as a string (in quotes) instead", path, v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", fmt.Errorf("%s has no value", path)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// formatElement formats an element of a list or a field of a struct, quoting strings and bytes so
+// that commas and brackets in them are not taken for more elements
+func formatElement(input *Argument, value interface{}, path string, do *definitions.Do) (string, error) {
+	arg, err := formatArgument(input, value, path, do)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := value.(string); ok && input != nil && quotableType(input.Type) {
+		return util.QuoteElement(arg), nil
+	}
+	return arg, nil
+}
+
+// quotableType is true for strings and bytes, which are quoted within lists and structs
+func quotableType(typ string) bool {
+	return typ == "string" || strings.HasPrefix(typ, "bytes")
+}
+
+// stringMap returns the map as decoded from yaml or json keyed by strings
+func stringMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		values := make(map[string]interface{}, len(m))
+		for key, value := range m {
+			values[fmt.Sprint(key)] = value
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func containsMap(values []interface{}) bool {
+	for _, value := range values {
+		if _, ok := stringMap(value); ok {
+			return true
+		}
+		if list, ok := value.([]interface{}); ok && containsMap(list) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsUnit is true when a value is given in one of the units of the package
+func containsUnit(values []interface{}, do *definitions.Do) bool {
+	units := util.PackageUnits(do)
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			if fields := strings.Fields(v); len(fields) == 2 {
+				if _, ok := units[strings.ToLower(fields[1])]; ok {
+					return true
+				}
+			}
+		case []interface{}:
+			if containsUnit(v, do) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsQuotable is true when a string within a list would need quoting once its variables are
+// replaced
+func containsQuotable(values []interface{}, nested bool, do *definitions.Do) bool {
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			if !nested {
+				continue
+			}
+			if arg, err := util.PreProcess(v, do); err == nil && util.QuoteElement(arg) != arg {
+				return true
+			}
+		case []interface{}:
+			if containsQuotable(v, true, do) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameNames(inputs []Argument, values map[string]interface{}) bool {
+	if len(inputs) != len(values) {
+		return false
+	}
+	for _, input := range inputs {
+		if _, ok := values[input.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedNames(values map[string]interface{}) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withUnit writes an argument given in one of the units of the package, such as 5 token, as the
+// integer it is. Other arguments are left as they are
+func withUnit(value string, do *definitions.Do) (string, error) {
+	units := util.PackageUnits(do)
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return value, nil
+	}
+	if _, ok := units[strings.ToLower(fields[1])]; !ok {
+		return value, nil
+	}
+	n, err := util.ParseInteger(value, units)
+	if err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
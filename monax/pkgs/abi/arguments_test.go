@@ -0,0 +1,165 @@
+package abi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+const ordersABI = `[
+{"type":"constructor","inputs":[{"name":"owner","type":"address"},{"name":"limit","type":"uint256"}]},
+{"type":"function","name":"place","inputs":[
+	{"name":"amount","type":"uint256"},
+	{"name":"order","type":"tuple","components":[
+		{"name":"owner","type":"address"},
+		{"name":"prices","type":"uint256[]"}]},
+	{"name":"notes","type":"string[2]"}]},
+{"type":"function","name":"cancel","inputs":[{"name":"id","type":"uint256"}]},
+{"type":"function","name":"cancel","inputs":[{"name":"owner","type":"address"}]}
+]`
+
+func ordersDo(t *testing.T) (*definitions.Do, func()) {
+	root, err := ioutil.TempDir("", "abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "Orders"), []byte(ordersABI), 0664); err != nil {
+		t.Fatal(err)
+	}
+	do := definitions.NowDo()
+	do.ABIPath = root
+	do.Package = &definitions.Package{
+		Jobs:  []*definitions.Job{{JobName: "owner", JobResult: "6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC"}},
+		Units: map[string]int{"token": 3},
+	}
+	return do, func() { os.RemoveAll(root) }
+}
+
+func TestPreProcessInputData(t *testing.T) {
+	do, cleanup := ordersDo(t)
+	defer cleanup()
+	order := map[interface{}]interface{}{"owner": "$owner", "prices": []interface{}{1, 2}}
+
+	for _, test := range []struct {
+		function    string
+		data        interface{}
+		constructor bool
+		expected    []string
+		err         string
+	}{
+		{"place", []interface{}{10, "[1,2]", []interface{}{"a", "$owner"}}, false,
+			[]string{"10", "[1,2]", "[a,6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC]"}, ""},
+		{"place", []interface{}{true, []interface{}{[]interface{}{1, 2}, []interface{}{3}}, 1e3}, false,
+			[]string{"true", "[[1,2],[3]]", "1000"}, ""},
+		{"place", []interface{}{123456789012345678901.0, "[1]", []interface{}{"a", "b"}}, false, nil,
+			"0 is given as 1.2345678901234568e+20, which is not an integer the yaml can hold exactly"},
+		{"place", []interface{}{1.5, "[1]", []interface{}{"a", "b"}}, false, nil,
+			"0 is given as 1.5, which is not an integer"},
+		{"place", map[interface{}]interface{}{"notes": []interface{}{"a", "b"}, "order": order, "amount": 10}, false,
+			[]string{"10", "(6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC,[1,2])", "[a,b]"}, ""},
+		{"place", []interface{}{10, order, []interface{}{"a", "b"}}, false,
+			[]string{"10", "(6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC,[1,2])", "[a,b]"}, ""},
+		{"place", []interface{}{10, "[1,2]", []interface{}{"hello, world", "[x]"}}, false,
+			[]string{"10", "[1,2]", `["hello, world","[x]"]`}, ""},
+		{"place", map[interface{}]interface{}{"notes": []interface{}{"(a)", ""}, "order": order, "amount": 10}, false,
+			[]string{"10", "(6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC,[1,2])", `["(a)",""]`}, ""},
+		{"place", []interface{}{"2 token", "[1,2]", []interface{}{"2 token", "b"}}, false,
+			[]string{"2000", "[1,2]", "[2 token,b]"}, ""},
+		{"place", []interface{}{"2 tokens", "[1,2]", []interface{}{"a", "b"}}, false,
+			[]string{"2 tokens", "[1,2]", "[a,b]"}, ""},
+		{"place", map[interface{}]interface{}{"notes": []interface{}{"a", "b"}, "amount": "1.5 token",
+			"order": map[interface{}]interface{}{"owner": "$owner", "prices": []interface{}{"1 token", "1e3"}}}, false,
+			[]string{"1500", "(6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC,[1000,1e3])", "[a,b]"}, ""},
+		{"", map[string]interface{}{"limit": 5, "owner": "$owner"}, true,
+			[]string{"6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC", "5"}, ""},
+		{"place", map[interface{}]interface{}{"amount": 10, "note": "a"}, false, nil,
+			"missing order, notes; unknown note"},
+		{"place", map[interface{}]interface{}{"amount": 10, "notes": []interface{}{},
+			"order": map[interface{}]interface{}{"owner": "$owner", "price": 1}}, false, nil,
+			"missing order.prices; unknown order.price"},
+		{"place", map[interface{}]interface{}{"amount": []interface{}{1}, "notes": []interface{}{}, "order": order}, false, nil,
+			"amount is given as a list but is of type uint256"},
+		{"place", map[interface{}]interface{}{"amount": order, "notes": []interface{}{}, "order": order}, false, nil,
+			"amount is given as a map but is not a struct"},
+		{"cancel", map[interface{}]interface{}{"id": 1, "owner": "$owner"}, false, nil,
+			"no overload of cancel takes the parameters id, owner"},
+		{"missing", map[interface{}]interface{}{"id": 1}, false, nil, "function missing is not in the abi"},
+		{"cancel", []interface{}{"2 token"}, false, nil,
+			"function cancel is overloaded, give one of the signatures cancel(uint256), cancel(address)"},
+	} {
+		function, args, err := PreProcessInputData("Orders", test.function, test.data, do, test.constructor)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected an error containing %q for %v, got %v", test.err, test.data, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %v: %v", test.data, err)
+			continue
+		}
+		if function != test.function {
+			t.Errorf("expected function %s, got %s", test.function, function)
+		}
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("expected %v to be processed to %q, got %q", test.data, test.expected, args)
+		}
+	}
+}
+
+func TestOverloadedInputData(t *testing.T) {
+	do, cleanup := ordersDo(t)
+	defer cleanup()
+	order := map[interface{}]interface{}{"owner": "$owner", "prices": []interface{}{1}}
+
+	for _, test := range []struct {
+		function string
+		data     interface{}
+		selected string
+		expected []string
+	}{
+		{"cancel", map[interface{}]interface{}{"owner": "$owner"}, "cancel(address)",
+			[]string{"6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC"}},
+		{"cancel", map[interface{}]interface{}{"id": 3}, "cancel(uint256)", []string{"3"}},
+		{"cancel(uint256)", []interface{}{3}, "cancel(uint256)", []string{"3"}},
+		{"cancel(uint256)", map[interface{}]interface{}{"id": 3}, "cancel(uint256)", []string{"3"}},
+		{"cancel(uint)", map[interface{}]interface{}{"id": 3}, "cancel(uint)", []string{"3"}},
+		{"cancel(uint)", []interface{}{"1 token"}, "cancel(uint)", []string{"1000"}},
+		{"place(uint256,(address,uint256[]),string[2])", []interface{}{1, order, []interface{}{"a", "b"}},
+			"place(uint256,(address,uint256[]),string[2])",
+			[]string{"1", "(6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC,[1])", "[a,b]"}},
+	} {
+		function, args, err := PreProcessInputData("Orders", test.function, test.data, do, false)
+		if err != nil {
+			t.Errorf("unexpected error for %s %v: %v", test.function, test.data, err)
+			continue
+		}
+		if function != test.selected || !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("expected %s %v to select %s %q, got %s %q", test.function, test.data, test.selected,
+				test.expected, function, args)
+			continue
+		}
+		// the selected function is packed as the overload the arguments were ordered for
+		selector, err := Selector(test.selected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packed, err := Packer(ordersABI, function, args...)
+		if err != nil {
+			t.Errorf("could not pack %s %q: %v", function, args, err)
+		} else if !bytes.Equal(packed[:4], selector) {
+			t.Errorf("expected %s to be packed with the selector of %s", function, test.selected)
+		}
+	}
+
+	if _, _, err := PreProcessInputData("Orders", "cancel(bool)", map[string]interface{}{"b": true}, do, false); err == nil ||
+		!strings.Contains(err.Error(), "no function in the abi has the signature cancel(bool)") {
+		t.Errorf("expected an error for a signature not in the abi, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func bigs(values ...int64) []*big.Int {
+	ints := make([]*big.Int, len(values))
+	for i, v := range values {
+		ints[i] = big.NewInt(v)
+	}
+	return ints
+}
+
+func words(values ...int64) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = append(packed, common.LeftPadBytes(big.NewInt(v).Bytes(), 32)...)
+	}
+	return packed
+}
+
+// the arrays go-ethereum packs correctly are packed the same and unpack back to the arguments
+func TestArraysRoundTrip(t *testing.T) {
+	address := common.HexToAddress("1040E6521541DAB4E7EE57F21226DD17CE9F0FB7")
+	for _, test := range []struct {
+		typ   string
+		arg   string
+		value interface{}
+	}{
+		{"uint256[]", "[1,2,3]", bigs(1, 2, 3)},
+		{"uint256[]", "[]", bigs()},
+		{"int256[]", "[-1,0,1]", bigs(-1, 0, 1)},
+		{"uint256[3]", "[4,5,6]", bigs(4, 5, 6)},
+		{"address[]", "[1040E6521541DAB4E7EE57F21226DD17CE9F0FB7,1040E6521541DAB4E7EE57F21226DD17CE9F0FB7]",
+			[]common.Address{address, address}},
+		{"bool[2]", "[true,false]", []bool{true, false}},
+		{"uint8[]", "[1,255]", []uint8{1, 255}},
+		{"int32[]", "[-7,7]", []int32{-7, 7}},
+		{"int64[2]", "[-9,9]", []int64{-9, 9}},
+	} {
+		abiData := `[{"type":"function","name":"f","inputs":[{"name":"","type":"` + test.typ +
+			`"}],"outputs":[{"name":"","type":"` + test.typ + `"}]}]`
+		abiSpec, err := MakeAbi(abiData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := abiSpec.Pack("f", test.value)
+		if err != nil {
+			t.Fatalf("geth could not pack %s %s: %v", test.typ, test.arg, err)
+		}
+		packed, err := Packer(abiData, "f", test.arg)
+		if err != nil {
+			t.Errorf("unexpected error packing %s %s: %v", test.typ, test.arg, err)
+			continue
+		}
+		if !bytes.Equal(packed, expected) {
+			t.Errorf("expected %s %s to be packed as geth packs it\n%X\ngot\n%X", test.typ, test.arg, expected, packed)
+		}
+		vars, err := Unpacker(abiData, "f", expected[4:], BytesAuto)
+		if err != nil {
+			t.Errorf("unexpected error unpacking %s %s: %v", test.typ, test.arg, err)
+		} else if vars[0].Value != test.arg {
+			t.Errorf("expected %s to be unpacked to %s, got %s", test.typ, test.arg, vars[0].Value)
+		}
+	}
+}
+
+// the arrays go-ethereum does not handle, checked against the encodings of the abi specification
+func TestArrays(t *testing.T) {
+	for _, test := range []struct {
+		inputs   string
+		args     []string
+		selector string
+		packed   []byte
+	}{
+		// the example of the solidity abi specification
+		{`[{"name":"","type":"uint256[][]"},{"name":"","type":"string[]"}]`,
+			[]string{"[[1,2],[3]]", "[one,two,three]"},
+			"g(uint256[][],string[])",
+			bytes.Join([][]byte{
+				words(0x40, 0x140, 2, 0x40, 0xa0, 2, 1, 2, 1, 3, 3, 0x60, 0xa0, 0xe0),
+				words(3), pad([]byte("one"), 32, false),
+				words(3), pad([]byte("two"), 32, false),
+				words(5), pad([]byte("three"), 32, false),
+			}, nil)},
+		// geth puts the string at 0x40 as if the fixed array took one word
+		{`[{"name":"","type":"uint256[2]"},{"name":"","type":"string"}]`,
+			[]string{"[1,2]", "marmots"},
+			"g(uint256[2],string)",
+			append(words(1, 2, 0x60, 7), pad([]byte("marmots"), 32, false)...)},
+		{`[{"name":"","type":"uint8[2][3]"}]`,
+			[]string{"[[1,2],[3,4],[5,6]]"},
+			"g(uint8[2][3])",
+			words(1, 2, 3, 4, 5, 6)},
+		{`[{"name":"","type":"bytes[]"},{"name":"","type":"bytes32[2]"}]`,
+			[]string{"[den,of marmots]", "[a,b]"},
+			"g(bytes[],bytes32[2])",
+			bytes.Join([][]byte{
+				words(0x60), pad([]byte("a"), 32, false), pad([]byte("b"), 32, false),
+				words(2, 0x40, 0x80),
+				words(3), pad([]byte("den"), 32, false),
+				words(10), pad([]byte("of marmots"), 32, false),
+			}, nil)},
+		{`[{"name":"","type":"string[2][]"}]`,
+			[]string{"[[a,b]]"},
+			"g(string[2][])",
+			bytes.Join([][]byte{
+				words(0x20, 1, 0x20, 0x40, 0x80),
+				words(1), pad([]byte("a"), 32, false),
+				words(1), pad([]byte("b"), 32, false),
+			}, nil)},
+	} {
+		abiData := `[{"type":"function","name":"g","inputs":` + test.inputs + `,"outputs":` + test.inputs + `}]`
+		packed, err := Packer(abiData, "g", test.args...)
+		if err != nil {
+			t.Errorf("unexpected error packing %v: %v", test.args, err)
+			continue
+		}
+		expected := append(crypto.Keccak256([]byte(test.selector))[:4], test.packed...)
+		if !bytes.Equal(packed, expected) {
+			t.Errorf("expected %v to be packed to\n%X\ngot\n%X", test.args, expected, packed)
+		}
+		vars, err := Unpacker(abiData, "g", packed[4:], BytesAuto)
+		if err != nil {
+			t.Errorf("unexpected error unpacking %v: %v", test.args, err)
+			continue
+		}
+		for i, arg := range test.args {
+			if vars[i].Value != arg {
+				t.Errorf("expected %s to be unpacked, got %s", arg, vars[i].Value)
+			}
+		}
+	}
+
+	for _, test := range []struct{ typ, arg string }{
+		{"uint256[2]", "[1,2,3]"},
+		{"uint256[]", "1,2"},
+		{"uint8[]", "[256]"},
+		{"uint256[2][]", "[[1,2],[3]]"},
+	} {
+		abiData := `[{"type":"function","name":"g","inputs":[{"name":"","type":"` + test.typ + `"}],"outputs":[]}]`
+		if _, err := Packer(abiData, "g", test.arg); err == nil {
+			t.Errorf("expected an error packing %s as %s", test.arg, test.typ)
+		}
+	}
+}
+
+// events are still decoded by geth
+func TestGetStringValueArrays(t *testing.T) {
+	abiSpec, err := MakeAbi(`[{"type":"function","name":"f","inputs":[{"name":"","type":"bytes32[2]"},{"name":"","type":"int256[]"}]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := abiSpec.Methods["f"].Inputs
+	var marmot [32]byte
+	copy(marmot[:], "marmot")
+	for _, test := range []struct {
+		value    interface{}
+		index    int
+		expected string
+	}{
+		{[][32]byte{marmot, {}}, 0, "[marmot,]"},
+		{[][]byte{[]byte("den"), []byte("of")}, 0, "[den,of]"},
+		{bigs(-1, 2), 1, "[-1,2]"},
+		{bigs(), 1, "[]"},
+	} {
+		value, err := getStringValue(test.value, inputs[test.index].Type, BytesAuto)
+		if err != nil {
+			t.Errorf("unexpected error getting the value of %v: %v", test.value, err)
+		} else if value != test.expected {
+			t.Errorf("expected %v to be %s, got %s", test.value, test.expected, value)
+		}
+	}
+}
+
+// strings within arrays and tuples may be quoted to hold commas and brackets, and are quoted when
+// unpacked if they hold them
+func TestQuotedElements(t *testing.T) {
+	for _, test := range []struct {
+		typ      string
+		arg      string
+		elements []string
+		unpacked string
+	}{
+		{"string[]", `["hello, world"]`, []string{"hello, world"}, `["hello, world"]`},
+		{"string[]", `[hello, world]`, []string{"hello", "world"}, `[hello,world]`},
+		{"string[]", `[ "(a]", "b\"c" ,d"e]`, []string{"(a]", `b"c`, `d"e`}, `["(a]","b\"c","d\"e"]`},
+		{"string[2]", `["",plain]`, []string{"", "plain"}, `["",plain]`},
+		{"(string,uint256)", `("a (b), c",5)`, []string{"a (b), c", "5"}, `("a (b), c",5)`},
+		{"string[][]", `[["x,y"],[z]]`, []string{`["x,y"]`, "[z]"}, `[["x,y"],[z]]`},
+	} {
+		input := `{"name":"","type":"` + test.typ + `"}`
+		if strings.HasPrefix(test.typ, "(") {
+			input = `{"name":"","type":"tuple","components":[{"name":"a","type":"string"},{"name":"b","type":"uint256"}]}`
+		}
+		abiData := `[{"type":"function","name":"f","inputs":[` + input + `],"outputs":[` + input + `]}]`
+		elements, err := SplitList(test.arg)
+		if err != nil {
+			t.Errorf("unexpected error splitting %s: %v", test.arg, err)
+		} else if !reflect.DeepEqual(elements, test.elements) {
+			t.Errorf("expected %s to be split into %q, got %q", test.arg, test.elements, elements)
+		}
+		packed, err := Packer(abiData, "f", test.arg)
+		if err != nil {
+			t.Errorf("unexpected error packing %s %s: %v", test.typ, test.arg, err)
+			continue
+		}
+		vars, err := Unpacker(abiData, "f", packed[4:], BytesAuto)
+		if err != nil {
+			t.Errorf("unexpected error unpacking %s %s: %v", test.typ, test.arg, err)
+		} else if vars[0].Value != test.unpacked {
+			t.Errorf("expected %s to be unpacked to %s, got %s", test.arg, test.unpacked, vars[0].Value)
+		}
+	}
+
+	for _, arg := range []string{`["unterminated]`, `["a" b]`} {
+		if _, err := SplitList(arg); err == nil {
+			t.Errorf("expected an error splitting %s", arg)
+		}
+	}
+}
+
+func TestPackBadAddress(t *testing.T) {
+	abiData := `[{"type":"function","name":"f","inputs":[{"name":"","type":"address[]"}],"outputs":[]}]`
+	for _, arg := range []string{"[1040E6521541DAB4E7EE57F21226DD17CE9F0FBZ]", "[0x1040E6521541DAB4E7EE57F21226DD17CE9F0F]"} {
+		if _, err := Packer(abiData, "f", arg); err == nil {
+			t.Errorf("expected an error packing %s", arg)
+		}
+	}
+	// addresses outside arrays are packed by go-ethereum
+	abiData = `[{"type":"function","name":"g","inputs":[{"name":"","type":"address"}],"outputs":[]}]`
+	for _, arg := range []string{"1040E6521541DAB4E7EE57F21226DD17CE9F0FBZ", "0x1040E6521541DAB4E7EE57F21226DD17CE9F0F",
+		"1040E6521541DAB4E7EE57F21226DD17CE9F0FBA00"} {
+		if _, err := Packer(abiData, "g", arg); err == nil {
+			t.Errorf("expected an error packing %s", arg)
+		}
+	}
+	if _, err := Packer(abiData, "g", "0x1040E6521541DAB4E7EE57F21226DD17CE9F0FBA"); err != nil {
+		t.Errorf("unexpected error packing an address: %v", err)
+	}
+}
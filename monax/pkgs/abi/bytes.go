@@ -0,0 +1,71 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The encodings bytes and fixed bytes are returned in
+const (
+	// BytesAuto, also given as auto, returns bytes as a string when they are printable UTF-8 and as
+	// hex otherwise
+	BytesAuto   = ""
+	BytesString = "string"
+	BytesHex    = "hex"
+)
+
+// CheckBytesEncoding checks the encoding is one bytes can be returned in
+func CheckBytesEncoding(encoding string) error {
+	switch encoding {
+	case BytesAuto, "auto", BytesString, BytesHex:
+		return nil
+	}
+	return fmt.Errorf("bytes encoding must be auto, %s or %s, not %s", BytesString, BytesHex, encoding)
+}
+
+// parseBytes reads an argument for bytes, as hex when prefixed by 0x and as the bytes of the string
+// otherwise
+func parseBytes(value string) ([]byte, error) {
+	if strings.HasPrefix(value, "0x") {
+		b, err := hex.DecodeString(value[2:])
+		if err != nil {
+			return nil, fmt.Errorf("%s is not hex encoded: %v", value, err)
+		}
+		return b, nil
+	}
+	return []byte(value), nil
+}
+
+// formatBytes gives bytes in the encoding. As a string the NUL padding is trimmed, as hex the bytes
+// are prefixed by 0x so they can be given back as arguments
+func formatBytes(b []byte, encoding string) string {
+	trimmed := bytes.Trim(b, "\x00")
+	switch encoding {
+	case BytesString:
+		return string(trimmed)
+	case BytesHex:
+		return "0x" + strings.ToUpper(hex.EncodeToString(b))
+	}
+	if printable(trimmed) {
+		return string(trimmed)
+	}
+	return "0x" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// printable is true for valid UTF-8 without control characters other than whitespace, so small
+// numbers held in bytes32, such as 0x01, are not taken for strings
+func printable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package abi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFormatBytes(t *testing.T) {
+	hash := common.Hex2Bytes("C5D2460186F7233C927E7DB2DCC703C0E500B653CA82273B7BFAD8045D85A470")
+	for _, test := range []struct {
+		bytes    []byte
+		encoding string
+		expected string
+	}{
+		{pad([]byte("marmot"), 32, false), BytesAuto, "marmot"},
+		{pad([]byte("marmot"), 32, false), "auto", "marmot"},
+		{pad([]byte("marmot"), 32, false), BytesString, "marmot"},
+		{[]byte("marmot"), BytesHex, "0x6D61726D6F74"},
+		{hash, BytesAuto, "0xC5D2460186F7233C927E7DB2DCC703C0E500B653CA82273B7BFAD8045D85A470"},
+		{[]byte{'a', 0, 'b'}, BytesAuto, "0x610062"},
+		{nil, BytesAuto, ""},
+		{pad([]byte{1}, 32, true), BytesAuto, "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{[]byte("line\nbreak\t"), "auto", "line\nbreak\t"},
+		{[]byte("\x1bmarmot"), BytesAuto, "0x1B6D61726D6F74"},
+	} {
+		if formatted := formatBytes(test.bytes, test.encoding); formatted != test.expected {
+			t.Errorf("expected %X in encoding %q to be %s, got %s", test.bytes, test.encoding, test.expected, formatted)
+		}
+	}
+	if err := CheckBytesEncoding("base64"); err == nil || !strings.Contains(err.Error(), "auto, string or hex") {
+		t.Errorf("expected an error naming the encodings checking an unknown encoding, got %v", err)
+	}
+}
+
+func TestHexBytes(t *testing.T) {
+	for _, test := range []struct {
+		typ, arg string
+		packed   []byte
+		hex      string
+	}{
+		{"bytes32", "0xdeadbeef", pad([]byte{0xde, 0xad, 0xbe, 0xef}, 32, false),
+			"0xDEADBEEF00000000000000000000000000000000000000000000000000000000"},
+		{"bytes4", "0xdeadbeef", pad([]byte{0xde, 0xad, 0xbe, 0xef}, 32, false), "0xDEADBEEF"},
+		{"bytes32", "marmot", pad([]byte("marmot"), 32, false),
+			"0x6D61726D6F740000000000000000000000000000000000000000000000000000"},
+		{"bytes", "0xdeadbeef", append(append(word(0x20), word(4)...), pad([]byte{0xde, 0xad, 0xbe, 0xef}, 32, false)...),
+			"0xDEADBEEF"},
+		{"bytes4[]", "[0xdeadbeef,0x01]", bytes.Join([][]byte{word(0x20), word(2),
+			pad([]byte{0xde, 0xad, 0xbe, 0xef}, 32, false), pad([]byte{1}, 32, false)}, nil), "[0xDEADBEEF,0x01000000]"},
+	} {
+		abiData := `[{"type":"function","name":"f","inputs":[{"name":"","type":"` + test.typ +
+			`"}],"outputs":[{"name":"","type":"` + test.typ + `"}]}]`
+		packed, err := Packer(abiData, "f", test.arg)
+		if err != nil {
+			t.Errorf("unexpected error packing %s as %s: %v", test.arg, test.typ, err)
+			continue
+		}
+		if !bytes.Equal(packed[4:], test.packed) {
+			t.Errorf("expected %s to be packed as %s to\n%X\ngot\n%X", test.arg, test.typ, test.packed, packed[4:])
+		}
+		vars, err := Unpacker(abiData, "f", test.packed, BytesHex)
+		if err != nil {
+			t.Errorf("unexpected error unpacking %s: %v", test.typ, err)
+		} else if vars[0].Value != test.hex {
+			t.Errorf("expected %s to be unpacked as %s, got %s", test.typ, test.hex, vars[0].Value)
+		}
+	}
+
+	for _, test := range []struct{ typ, arg string }{
+		{"bytes4", "0xdeadbeef01"},
+		{"bytes32", "0xmarmot"},
+		{"bytes", "0x123"},
+	} {
+		abiData := `[{"type":"function","name":"f","inputs":[{"name":"","type":"` + test.typ + `"}],"outputs":[]}]`
+		if _, err := Packer(abiData, "f", test.arg); err == nil {
+			t.Errorf("expected an error packing %s as %s", test.arg, test.typ)
+		}
+	}
+}
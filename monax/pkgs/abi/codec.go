@@ -0,0 +1,770 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/util"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The abi of go-ethereum we use predates abi v2 and fails to parse an abi with tuples (structs) in
+// it. Its arrays are limited to one dimension, it packs arrays of strings and bytes without their
+// offsets and misplaces dynamic arguments following fixed arrays. Functions taking or returning
+// tuples or arrays are packed and unpacked here instead, the remaining entries of the abi are left
+// to go-ethereum.
+
+// Argument is an input or output of a function as declared in the abi, tuples listing their fields
+// as components
+type Argument struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Components []Argument `json:"components"`
+	Indexed    bool       `json:"indexed"`
+}
+
+// Function is a function, constructor or event of the abi
+type Function struct {
+	Type    string     `json:"type"`
+	Name    string     `json:"name"`
+	Inputs  []Argument `json:"inputs"`
+	Outputs []Argument `json:"outputs"`
+
+	// constant is set by older compilers, the state mutability (view or pure) by newer ones
+	Constant        bool   `json:"constant"`
+	StateMutability string `json:"stateMutability"`
+}
+
+// ReadOnly is true for functions which do not change the state, so can be queried rather than
+// transacted with
+func (fn Function) ReadOnly() bool {
+	return fn.Constant || fn.StateMutability == "view" || fn.StateMutability == "pure"
+}
+
+// ReadFunctions parses the entries of an abi
+func ReadFunctions(abiData string) ([]Function, error) {
+	var functions []Function
+	if abiData == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(abiData), &functions); err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+// Signature is the canonical signature of the function the selector is the hash of
+func (fn Function) Signature() (string, error) {
+	types := make([]string, len(fn.Inputs))
+	for i, input := range fn.Inputs {
+		if _, err := newType(input); err != nil {
+			return "", err
+		}
+		types[i] = input.canonicalType()
+	}
+	return signature(fn.Name, types), nil
+}
+
+// canonicalType is the form of the type used in the signature of a function: int and uint with
+// their size and tuples as the canonical types of their fields in brackets
+func (arg Argument) canonicalType() string {
+	if strings.HasPrefix(arg.Type, "tuple") {
+		fields := make([]string, len(arg.Components))
+		for i, component := range arg.Components {
+			fields[i] = component.canonicalType()
+		}
+		return "(" + strings.Join(fields, ",") + ")" + strings.TrimPrefix(arg.Type, "tuple")
+	}
+	base, suffix := arg.Type, ""
+	if i := strings.Index(base, "["); i >= 0 {
+		base, suffix = base[:i], base[i:]
+	}
+	switch base {
+	case "int", "uint":
+		base += "256"
+	}
+	return base + suffix
+}
+
+func (fn Function) usesTuples() bool {
+	return usesTuples(fn.Inputs) || usesTuples(fn.Outputs)
+}
+
+func (fn Function) usesArrays() bool {
+	return usesArrays(fn.Inputs) || usesArrays(fn.Outputs)
+}
+
+// usesArrays is true for arrays of any dimension, but not for bytes
+func usesArrays(args []Argument) bool {
+	for _, arg := range args {
+		if strings.HasSuffix(arg.Type, "]") {
+			return true
+		}
+	}
+	return false
+}
+
+func usesTuples(args []Argument) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg.Type, "tuple") {
+			return true
+		}
+	}
+	return false
+}
+
+// codecFunction returns the function of the abi with the name or signature, or the constructor
+// for an empty name, when it is packed here rather than by go-ethereum: when it takes or returns
+// tuples or arrays, or is given by its signature to pick one of several overloads, go-ethereum
+// keeping only one of them. The bare name of an overloaded function is ambiguous
+func codecFunction(abiData, name string) (*Function, error) {
+	if name == "()" {
+		// the fallback function, which has nothing to pack
+		return nil, nil
+	}
+	functions, err := ReadFunctions(abiData)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		for i, fn := range functions {
+			if fn.Type == "constructor" && (fn.usesTuples() || fn.usesArrays()) {
+				return &functions[i], nil
+			}
+		}
+		return nil, nil
+	}
+	overloads, err := findFunctions(functions, name)
+	if err != nil || len(overloads) == 0 {
+		return nil, err
+	}
+	if len(overloads) > 1 {
+		signatures := make([]string, len(overloads))
+		for i, fn := range overloads {
+			if signatures[i], err = fn.Signature(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("function %s is overloaded, give one of the signatures %s", name,
+			strings.Join(signatures, ", "))
+	}
+	fn := overloads[0]
+	if fn.usesTuples() || fn.usesArrays() || strings.Contains(name, "(") {
+		return &fn, nil
+	}
+	return nil, nil
+}
+
+// findFunctions returns the overloads of the function given by name, or the function given by
+// signature such as transfer(address,uint256)
+func findFunctions(functions []Function, name string) ([]Function, error) {
+	var signature string
+	if strings.Contains(name, "(") {
+		fn, err := ParseSignature(name)
+		if err != nil {
+			return nil, err
+		}
+		if signature, err = fn.Signature(); err != nil {
+			return nil, err
+		}
+	}
+	var found []Function
+	for _, fn := range functions {
+		if fn.Type == "event" || fn.Type == "constructor" || fn.Type == "fallback" {
+			continue
+		}
+		if signature == "" && fn.Name == name {
+			found = append(found, fn)
+		} else if signature != "" {
+			if s, err := fn.Signature(); err == nil && s == signature {
+				found = append(found, fn)
+			}
+		}
+	}
+	if signature != "" && len(found) == 0 {
+		return nil, fmt.Errorf("no function in the abi has the signature %s", signature)
+	}
+	return found, nil
+}
+
+// withoutTuples removes the entries using tuples from the abi so go-ethereum can parse the rest
+func withoutTuples(abiData string) (string, error) {
+	if !strings.Contains(abiData, "tuple") {
+		return abiData, nil
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal([]byte(abiData), &entries); err != nil {
+		return "", err
+	}
+	var kept []json.RawMessage
+	for _, raw := range entries {
+		var fn Function
+		if err := json.Unmarshal(raw, &fn); err != nil {
+			return "", err
+		}
+		if !fn.usesTuples() {
+			kept = append(kept, raw)
+		}
+	}
+	b, err := json.Marshal(kept)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Pack packs the arguments, given in the string form the package gives them in with arrays as
+// [a,b] and tuples as (a,b), prefixed by the selector unless the function is the constructor
+func (fn Function) Pack(args ...string) ([]byte, error) {
+	if len(args) != len(fn.Inputs) {
+		return nil, fmt.Errorf("Invalid number of arguments asked to be packed, expected %v, got %v", len(fn.Inputs), len(args))
+	}
+	types, err := newTypes(fn.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := encodeSequence(types, args)
+	if err != nil {
+		return nil, err
+	}
+	if fn.Type == "constructor" {
+		return packed, nil
+	}
+	signature, err := fn.Signature()
+	if err != nil {
+		return nil, err
+	}
+	return append(crypto.Keccak256([]byte(signature))[:4], packed...), nil
+}
+
+// Unpack decodes the return of the function into a variable per output, followed by a variable per
+// field of the tuples returned (output.field) and per element of the arrays of tuples
+// (output[0].field). Bytes are returned in the encoding
+func (fn Function) Unpack(data []byte, encoding string) ([]*definitions.Variable, error) {
+	if len(fn.Outputs) == 0 {
+		return nil, nil
+	}
+	types, err := newTypes(fn.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decodeSequence(types, data, encoding)
+	if err != nil {
+		return nil, err
+	}
+	var vars, nested []*definitions.Variable
+	for i, typ := range types {
+		name := fn.Outputs[i].Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		vars = append(vars, &definitions.Variable{Name: name, Value: typ.format(values[i])})
+		nested = append(nested, typ.nestedVariables(name, values[i])...)
+	}
+	return append(vars, nested...), nil
+}
+
+// UnpackInput decodes the data of a call, the selector followed by the arguments, into a variable
+// per input of the function, or of the overload the selector is of when given by name
+func UnpackInput(abiData, name string, data []byte, encoding string) ([]*definitions.Variable, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("call data %X is shorter than a selector", data)
+	}
+	functions, err := ReadFunctions(abiData)
+	if err != nil {
+		return nil, err
+	}
+	overloads, err := findFunctions(functions, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range overloads {
+		signature, err := fn.Signature()
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(crypto.Keccak256([]byte(signature))[:4], data[:4]) {
+			return Function{Name: fn.Name, Outputs: fn.Inputs}.Unpack(data[4:], encoding)
+		}
+	}
+	return nil, fmt.Errorf("no function %s in the abi has the selector %X", name, data[:4])
+}
+
+// abiType is a parsed abi type
+type abiType struct {
+	// uint, int, address, bool, string, bytes, fixedbytes, tuple or array
+	kind string
+	// bits of integers, bytes of fixed bytes
+	size int
+	// element of arrays, the length being -1 for dynamic arrays
+	elem   *abiType
+	length int
+	// fields of tuples
+	fields []*abiType
+	names  []string
+}
+
+var arraySuffix = regexp.MustCompile(`^(.*)\[([0-9]*)\]$`)
+
+func newTypes(args []Argument) ([]*abiType, error) {
+	types := make([]*abiType, len(args))
+	for i, arg := range args {
+		var err error
+		if types[i], err = newType(arg); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+func newType(arg Argument) (*abiType, error) {
+	if match := arraySuffix.FindStringSubmatch(arg.Type); match != nil {
+		elem, err := newType(Argument{Name: arg.Name, Type: match[1], Components: arg.Components})
+		if err != nil {
+			return nil, err
+		}
+		length := -1
+		if match[2] != "" {
+			if length, err = strconv.Atoi(match[2]); err != nil {
+				return nil, err
+			}
+		}
+		return &abiType{kind: "array", elem: elem, length: length}, nil
+	}
+
+	switch typ := arg.Type; {
+	case typ == "tuple":
+		t := &abiType{kind: "tuple"}
+		for _, component := range arg.Components {
+			field, err := newType(component)
+			if err != nil {
+				return nil, err
+			}
+			t.fields = append(t.fields, field)
+			t.names = append(t.names, component.Name)
+		}
+		return t, nil
+	case typ == "address" || typ == "bool" || typ == "string" || typ == "bytes":
+		return &abiType{kind: typ}, nil
+	case strings.HasPrefix(typ, "uint") || strings.HasPrefix(typ, "int"):
+		kind := "int"
+		if strings.HasPrefix(typ, "uint") {
+			kind = "uint"
+		}
+		size := 256
+		if bits := strings.TrimPrefix(typ, kind); bits != "" {
+			var err error
+			if size, err = strconv.Atoi(bits); err != nil || size == 0 || size > 256 || size%8 != 0 {
+				return nil, fmt.Errorf("invalid abi type %s", typ)
+			}
+		}
+		return &abiType{kind: kind, size: size}, nil
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(typ, "bytes"))
+		if err != nil || size == 0 || size > 32 {
+			return nil, fmt.Errorf("invalid abi type %s", typ)
+		}
+		return &abiType{kind: "fixedbytes", size: size}, nil
+	}
+	return nil, fmt.Errorf("unsupported abi type %s", arg.Type)
+}
+
+// String is the canonical form of the type as used in signatures
+func (t *abiType) String() string {
+	switch t.kind {
+	case "array":
+		if t.length < 0 {
+			return t.elem.String() + "[]"
+		}
+		return fmt.Sprintf("%s[%d]", t.elem, t.length)
+	case "tuple":
+		fields := make([]string, len(t.fields))
+		for i, field := range t.fields {
+			fields[i] = field.String()
+		}
+		return "(" + strings.Join(fields, ",") + ")"
+	case "uint", "int":
+		return fmt.Sprintf("%s%d", t.kind, t.size)
+	case "fixedbytes":
+		return fmt.Sprintf("bytes%d", t.size)
+	}
+	return t.kind
+}
+
+func (t *abiType) dynamic() bool {
+	switch t.kind {
+	case "string", "bytes":
+		return true
+	case "array":
+		return t.length < 0 || t.elem.dynamic()
+	case "tuple":
+		for _, field := range t.fields {
+			if field.dynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize is the size of the type in the head of a sequence, the offset of its tail for dynamic types
+func (t *abiType) headSize() int {
+	if t.dynamic() {
+		return 32
+	}
+	switch t.kind {
+	case "array":
+		return t.length * t.elem.headSize()
+	case "tuple":
+		size := 0
+		for _, field := range t.fields {
+			size += field.headSize()
+		}
+		return size
+	}
+	return 32
+}
+
+func (t *abiType) encode(value string) ([]byte, error) {
+	switch t.kind {
+	case "tuple":
+		values, err := splitList(value, '(', ')')
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != len(t.fields) {
+			return nil, fmt.Errorf("%s has %d fields, got %s", t, len(t.fields), value)
+		}
+		return encodeSequence(t.fields, values)
+	case "array":
+		values, err := splitList(value, '[', ']')
+		if err != nil {
+			return nil, err
+		}
+		types := make([]*abiType, len(values))
+		for i := range types {
+			types[i] = t.elem
+		}
+		if t.length >= 0 {
+			if len(values) != t.length {
+				return nil, fmt.Errorf("%s has %d elements, got %s", t, t.length, value)
+			}
+			return encodeSequence(types, values)
+		}
+		packed, err := encodeSequence(types, values)
+		if err != nil {
+			return nil, err
+		}
+		return append(math.PaddedBigBytes(big.NewInt(int64(len(values))), 32), packed...), nil
+	case "string", "bytes":
+		b := []byte(value)
+		if t.kind == "bytes" {
+			var err error
+			if b, err = parseBytes(value); err != nil {
+				return nil, err
+			}
+		}
+		length := math.PaddedBigBytes(big.NewInt(int64(len(b))), 32)
+		return append(length, common.RightPadBytes(b, (len(b)+31)/32*32)...), nil
+	case "fixedbytes":
+		b, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > t.size {
+			return nil, fmt.Errorf("%s does not fit in %s", value, t)
+		}
+		return common.RightPadBytes(b, 32), nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return math.PaddedBigBytes(big.NewInt(1), 32), nil
+		}
+		return make([]byte, 32), nil
+	case "address":
+		b, err := parseAddress(value)
+		if err != nil {
+			return nil, err
+		}
+		return common.LeftPadBytes(b, 32), nil
+	case "uint", "int":
+		i, err := util.ParseInteger(value, nil)
+		if err != nil {
+			return nil, err
+		}
+		min, max := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), uint(t.size))
+		if t.kind == "int" {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+			return nil, fmt.Errorf("%s does not fit in %s", value, t)
+		}
+		return math.PaddedBigBytes(math.U256(i), 32), nil
+	}
+	return nil, fmt.Errorf("cannot encode %s", t)
+}
+
+// encodeSequence encodes the values of a tuple, with static values in place and dynamic values
+// after all of them, referred to by their offset
+func encodeSequence(types []*abiType, values []string) ([]byte, error) {
+	headSize := 0
+	for _, t := range types {
+		headSize += t.headSize()
+	}
+	var head, tail []byte
+	for i, t := range types {
+		packed, err := t.encode(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if t.dynamic() {
+			head = append(head, math.PaddedBigBytes(big.NewInt(int64(headSize+len(tail))), 32)...)
+			tail = append(tail, packed...)
+		} else {
+			head = append(head, packed...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// decoded values are strings for elementary types and slices of values for arrays and tuples
+func (t *abiType) decode(data []byte, encoding string) (interface{}, error) {
+	switch t.kind {
+	case "tuple":
+		return decodeSequence(t.fields, data, encoding)
+	case "array":
+		length := t.length
+		if length < 0 {
+			n, err := readLength(data, 0)
+			if err != nil {
+				return nil, err
+			}
+			length, data = n, data[32:]
+		}
+		types := make([]*abiType, length)
+		for i := range types {
+			types[i] = t.elem
+		}
+		return decodeSequence(types, data, encoding)
+	}
+
+	if len(data) < 32 {
+		return nil, fmt.Errorf("not enough data to decode %s", t)
+	}
+	word := data[:32]
+	switch t.kind {
+	case "string", "bytes":
+		n, err := readLength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 32+n {
+			return nil, fmt.Errorf("not enough data to decode %s of length %d", t, n)
+		}
+		if t.kind == "bytes" {
+			return formatBytes(data[32:32+n], encoding), nil
+		}
+		return string(data[32 : 32+n]), nil
+	case "fixedbytes":
+		return formatBytes(word[:t.size], encoding), nil
+	case "bool":
+		return strconv.FormatBool(word[31] == 1), nil
+	case "address":
+		return strings.ToUpper(common.Bytes2Hex(word[12:])), nil
+	case "uint":
+		return new(big.Int).SetBytes(word).String(), nil
+	case "int":
+		return math.S256(new(big.Int).SetBytes(word)).String(), nil
+	}
+	return nil, fmt.Errorf("cannot decode %s", t)
+}
+
+func decodeSequence(types []*abiType, data []byte, encoding string) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	offset := 0
+	for i, t := range types {
+		var err error
+		if t.dynamic() {
+			var tail int
+			if tail, err = readLength(data, offset); err != nil {
+				return nil, err
+			}
+			if tail > len(data) {
+				return nil, fmt.Errorf("offset %d of %s is beyond the data", tail, t)
+			}
+			values[i], err = t.decode(data[tail:], encoding)
+		} else {
+			if offset > len(data) {
+				return nil, fmt.Errorf("not enough data to decode %s", t)
+			}
+			values[i], err = t.decode(data[offset:], encoding)
+		}
+		if err != nil {
+			return nil, err
+		}
+		offset += t.headSize()
+	}
+	return values, nil
+}
+
+// readLength reads a word holding an offset or a length
+func readLength(data []byte, offset int) (int, error) {
+	if len(data) < offset+32 {
+		return 0, fmt.Errorf("not enough data to read a length")
+	}
+	n := new(big.Int).SetBytes(data[offset : offset+32])
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("length %s is beyond the data", n)
+	}
+	return int(n.Int64()), nil
+}
+
+// format gives a decoded value in the string form arguments are given in, quoting strings and
+// bytes within arrays and tuples as needed
+func (t *abiType) format(value interface{}) string {
+	switch t.kind {
+	case "tuple", "array":
+		values := value.([]interface{})
+		formatted := make([]string, len(values))
+		for i, v := range values {
+			elem := t.elem
+			if t.kind == "tuple" {
+				elem = t.fields[i]
+			}
+			formatted[i] = elem.format(v)
+			switch elem.kind {
+			case "string", "bytes", "fixedbytes":
+				formatted[i] = util.QuoteElement(formatted[i])
+			}
+		}
+		if t.kind == "tuple" {
+			return "(" + strings.Join(formatted, ",") + ")"
+		}
+		return "[" + strings.Join(formatted, ",") + "]"
+	}
+	return value.(string)
+}
+
+// nestedVariables are the variables for the fields of a tuple and the elements of an array of tuples
+func (t *abiType) nestedVariables(name string, value interface{}) []*definitions.Variable {
+	var vars []*definitions.Variable
+	switch {
+	case t.kind == "tuple":
+		for i, v := range value.([]interface{}) {
+			fieldName := t.names[i]
+			if fieldName == "" {
+				fieldName = strconv.Itoa(i)
+			}
+			fieldName = name + "." + fieldName
+			vars = append(vars, &definitions.Variable{Name: fieldName, Value: t.fields[i].format(v)})
+			vars = append(vars, t.fields[i].nestedVariables(fieldName, v)...)
+		}
+	case t.kind == "array" && usesTupleType(t.elem):
+		for i, v := range value.([]interface{}) {
+			elemName := fmt.Sprintf("%s[%d]", name, i)
+			vars = append(vars, &definitions.Variable{Name: elemName, Value: t.elem.format(v)})
+			vars = append(vars, t.elem.nestedVariables(elemName, v)...)
+		}
+	}
+	return vars
+}
+
+func usesTupleType(t *abiType) bool {
+	return t.kind == "tuple" || t.kind == "array" && usesTupleType(t.elem)
+}
+
+// SplitList splits an array given as [a,b] or a tuple given as (a,b) into its elements
+func SplitList(value string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "(") {
+		return splitList(value, '(', ')')
+	}
+	return splitList(value, '[', ']')
+}
+
+// splitList splits [a,b] or (a,b) into its elements, leaving nested lists and tuples whole. An
+// element may be quoted, see util.QuoteElement, to hold commas or brackets and is then unquoted
+func splitList(value string, open, close byte) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != open || value[len(value)-1] != close {
+		return nil, fmt.Errorf("expected %s to be enclosed in %c%c", value, open, close)
+	}
+	value = value[1 : len(value)-1]
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var elements []string
+	depth, start := 0, 0
+	// quotes only open a string at the start of an element, so a quote within an element is kept
+	elementStart := true
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if elementStart {
+			if c == ' ' || c == '\t' {
+				continue
+			}
+			elementStart = false
+			if c == '"' {
+				n := util.QuotedLength(value[i:])
+				if n < 0 {
+					return nil, fmt.Errorf("unterminated quote in %s", value)
+				}
+				i += n - 1
+				continue
+			}
+		}
+		switch c {
+		case '[', '(':
+			depth++
+			elementStart = true
+		case ']', ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced brackets in %s", value)
+			}
+		case ',':
+			elementStart = true
+			if depth == 0 {
+				elements = append(elements, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced brackets in %s", value)
+	}
+	elements = append(elements, strings.TrimSpace(value[start:]))
+	for i, element := range elements {
+		var err error
+		if elements[i], err = util.UnquoteElement(element); err != nil {
+			return nil, err
+		}
+	}
+	return elements, nil
+}
+
+// parseAddress reads an address given as 40 hex digits, with or without 0x
+func parseAddress(value string) ([]byte, error) {
+	address := strings.TrimPrefix(value, "0x")
+	if len(address) != 40 {
+		return nil, fmt.Errorf("%s is not an address", value)
+	}
+	b, err := hex.DecodeString(address)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an address: %v", value, err)
+	}
+	return b, nil
+}
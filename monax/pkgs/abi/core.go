@@ -1,7 +1,6 @@
 package abi
 
 import (
-	"bytes"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -18,7 +17,7 @@ import (
 )
 
 func ReadAbiFormulateCall(abiLocation string, funcName string, args []string, do *definitions.Do) ([]byte, error) {
-	abiSpecBytes, err := util.ReadAbi(do.ABIPath, abiLocation)
+	abiSpecBytes, err := util.ReadAbi(do, abiLocation)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -31,15 +30,16 @@ func ReadAbiFormulateCall(abiLocation string, funcName string, args []string, do
 	return Packer(abiSpecBytes, funcName, args...)
 }
 
-func ReadAndDecodeContractReturn(abiLocation, funcName string, resultRaw []byte, do *definitions.Do) ([]*definitions.Variable, error) {
-	abiSpecBytes, err := util.ReadAbi(do.ABIPath, abiLocation)
+// ReadAndDecodeContractReturn decodes the return of a function, returning bytes in the encoding
+func ReadAndDecodeContractReturn(abiLocation, funcName string, resultRaw []byte, encoding string, do *definitions.Do) ([]*definitions.Variable, error) {
+	abiSpecBytes, err := util.ReadAbi(do, abiLocation)
 	if err != nil {
 		return nil, err
 	}
 	log.WithField("=>", abiSpecBytes).Debug("ABI Specification (Decode)")
 
 	// Unpack the result
-	return Unpacker(abiSpecBytes, funcName, resultRaw)
+	return Unpacker(abiSpecBytes, funcName, resultRaw, encoding)
 }
 
 func MakeAbi(abiData string) (ethAbi.ABI, error) {
@@ -47,6 +47,11 @@ func MakeAbi(abiData string) (ethAbi.ABI, error) {
 		return ethAbi.ABI{}, nil
 	}
 
+	// go-ethereum cannot parse tuples, entries using them are handled by Function
+	abiData, err := withoutTuples(abiData)
+	if err != nil {
+		return ethAbi.ABI{}, err
+	}
 	abiSpec, err := ethAbi.JSON(strings.NewReader(abiData))
 	if err != nil {
 		return ethAbi.ABI{}, err
@@ -57,6 +62,12 @@ func MakeAbi(abiData string) (ethAbi.ABI, error) {
 
 //Convenience Packing Functions
 func Packer(abiData, funcName string, args ...string) ([]byte, error) {
+	if fn, err := codecFunction(abiData, funcName); err != nil {
+		return nil, err
+	} else if fn != nil {
+		return fn.Pack(args...)
+	}
+
 	abiSpec, err := MakeAbi(abiData)
 	if err != nil {
 		return nil, err
@@ -101,119 +112,34 @@ func getPackingTypes(abiSpec ethAbi.ABI, methodName string, args ...string) ([]i
 	return values, nil
 }
 
+// packInterfaceValue converts an argument to the value go-ethereum packs for its type. Arrays are
+// packed by Function, bytes and fixed bytes being the only array types left to go-ethereum
 func packInterfaceValue(typ ethAbi.Type, val string) (interface{}, error) {
 	if typ.IsArray || typ.IsSlice {
-
-		//check for fixed byte types and bytes types
-		if typ.T == ethAbi.BytesTy {
-			bytez := bytes.NewBufferString(val)
-			return common.RightPadBytes(bytez.Bytes(), bytez.Len()%32), nil
-		} else if typ.T == ethAbi.FixedBytesTy {
-			bytez := bytes.NewBufferString(val)
-			return common.RightPadBytes(bytez.Bytes(), typ.SliceSize), nil
-		} else if typ.Elem.T == ethAbi.BytesTy || typ.Elem.T == ethAbi.FixedBytesTy {
-			val = strings.Trim(val, "[]")
-			arr := strings.Split(val, ",")
-			var sliceOfFixedBytes [][]byte
-			for _, str := range arr {
-				bytez := bytes.NewBufferString(str)
-				sliceOfFixedBytes = append(sliceOfFixedBytes, common.RightPadBytes(bytez.Bytes(), 32))
+		switch typ.T {
+		case ethAbi.BytesTy:
+			return parseBytes(val)
+		case ethAbi.FixedBytesTy:
+			bytez, err := parseBytes(val)
+			if err != nil {
+				return nil, err
 			}
-			return sliceOfFixedBytes, nil
-		} else {
-			val = strings.Trim(val, "[]")
-			arr := strings.Split(val, ",")
-			var values interface{}
-
-			for i := 0; i < typ.SliceSize; i++ {
-				value, err := packInterfaceValue(*typ.Elem, arr[i])
-				if err != nil {
-					return nil, err
-				}
-				if value == nil {
-					return nil, nil
-				}
-				//var bigIntValue = (*big.Int)(nil)
-				switch value := value.(type) {
-				case string:
-					var ok bool
-					if values, ok = values.([]string); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]string), value)
-				case bool:
-					var ok bool
-					if values, ok = values.([]bool); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]bool), value)
-				case uint8:
-					var ok bool
-					if values, ok = values.([]uint8); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]uint8), value)
-				case uint16:
-					var ok bool
-					if values, ok = values.([]uint16); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]uint16), value)
-				case uint32:
-					var ok bool
-					if values, ok = values.([]uint32); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]uint32), value)
-				case uint64:
-					var ok bool
-					if values, ok = values.([]uint64); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]uint64), value)
-				case int8:
-					var ok bool
-					if values, ok = values.([]int8); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]int8), value)
-				case int16:
-					var ok bool
-					if values, ok = values.([]int16); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]int16), value)
-				case int32:
-					var ok bool
-					if values, ok = values.([]uint32); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]int32), value)
-				case int64:
-					var ok bool
-					if values, ok = values.([]uint64); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-					values = append(values.([]int64), value)
-				case *big.Int:
-					var ok bool
-					if values, ok = values.([]*big.Int); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-
-					values = append(values.([]*big.Int), value)
-				case common.Address:
-					var ok bool
-					if values, ok = values.([]common.Address); ok {
-						fmt.Printf("n=%#v\n", value)
-					}
-
-					values = append(values.([]common.Address), value)
-				}
+			if len(bytez) > typ.SliceSize {
+				return nil, fmt.Errorf("%s does not fit in %s", val, typ)
 			}
-			return values, nil
+			return common.RightPadBytes(bytez, typ.SliceSize), nil
+		default:
+			return nil, fmt.Errorf("cannot pack array %s with go-ethereum", typ)
 		}
 	} else {
+		if typ.T == ethAbi.IntTy || typ.T == ethAbi.UintTy {
+			// integers may be given in hex, with underscores or in scientific notation
+			i, err := util.ParseInteger(val, nil)
+			if err != nil {
+				return nil, err
+			}
+			val = i.String()
+		}
 		switch typ.T {
 		case ethAbi.IntTy:
 			switch typ.Size {
@@ -286,20 +212,35 @@ func packInterfaceValue(typ ethAbi.Type, val string) (interface{}, error) {
 		case ethAbi.StringTy:
 			return val, nil
 		case ethAbi.AddressTy:
-			return common.HexToAddress(val), nil
+			address, err := parseAddress(val)
+			if err != nil {
+				return nil, err
+			}
+			return common.BytesToAddress(address), nil
 		default:
 			return nil, fmt.Errorf("Could not get valid type from input")
 		}
 	}
 }
 
-func Unpacker(abiData, name string, data []byte) ([]*definitions.Variable, error) {
+// Unpacker decodes the return of a function into a variable per output, returning bytes in the
+// encoding
+func Unpacker(abiData, name string, data []byte, encoding string) ([]*definitions.Variable, error) {
+	if fn, err := codecFunction(abiData, name); err != nil {
+		return nil, err
+	} else if fn != nil {
+		return fn.Unpack(data, encoding)
+	}
 
 	abiSpec, err := MakeAbi(abiData)
 	if err != nil {
 		return []*definitions.Variable{}, err
 	}
 
+	return unpackMethod(abiSpec, name, data, encoding)
+}
+
+func unpackMethod(abiSpec ethAbi.ABI, name string, data []byte, encoding string) ([]*definitions.Variable, error) {
 	numArgs, err := numReturns(abiSpec, name)
 	if err != nil {
 		return nil, err
@@ -313,14 +254,14 @@ func Unpacker(abiData, name string, data []byte) ([]*definitions.Variable, error
 		if err != nil {
 			return []*definitions.Variable{}, err
 		}
-		return formatUnpackedReturn(abiSpec, name, unpacked)
+		return formatUnpackedReturn(abiSpec, name, encoding, unpacked)
 	} else {
 		var unpacked []interface{}
 		err = abiSpec.Unpack(&unpacked, name, data)
 		if err != nil {
 			return []*definitions.Variable{}, err
 		}
-		return formatUnpackedReturn(abiSpec, name, unpacked)
+		return formatUnpackedReturn(abiSpec, name, encoding, unpacked)
 	}
 
 }
@@ -343,7 +284,7 @@ func numReturns(abiSpec ethAbi.ABI, methodName string) (uint, error) {
 	}
 }
 
-func formatUnpackedReturn(abiSpec ethAbi.ABI, methodName string, values ...interface{}) ([]*definitions.Variable, error) {
+func formatUnpackedReturn(abiSpec ethAbi.ABI, methodName, encoding string, values ...interface{}) ([]*definitions.Variable, error) {
 	var returnVars []*definitions.Variable
 	method, exist := abiSpec.Methods[methodName]
 	if !exist {
@@ -353,7 +294,7 @@ func formatUnpackedReturn(abiSpec ethAbi.ABI, methodName string, values ...inter
 	if len(method.Outputs) > 1 {
 		slice := reflect.ValueOf(reflect.ValueOf(values).Index(0).Interface())
 		for i, output := range method.Outputs {
-			arg, err := getStringValue(slice.Index(i).Interface(), output.Type)
+			arg, err := getStringValue(slice.Index(i).Interface(), output.Type, encoding)
 			if err != nil {
 				return nil, err
 			}
@@ -373,7 +314,7 @@ func formatUnpackedReturn(abiSpec ethAbi.ABI, methodName string, values ...inter
 	} else {
 		value := values[0]
 		output := method.Outputs[0]
-		arg, err := getStringValue(value, output.Type)
+		arg, err := getStringValue(value, output.Type, encoding)
 		if err != nil {
 			return nil, err
 		}
@@ -393,31 +334,30 @@ func formatUnpackedReturn(abiSpec ethAbi.ABI, methodName string, values ...inter
 	return returnVars, nil
 }
 
-func getStringValue(value interface{}, typ ethAbi.Type) (string, error) {
+func getStringValue(value interface{}, typ ethAbi.Type, encoding string) (string, error) {
 
 	if typ.IsSlice || typ.IsArray {
 		if typ.T == ethAbi.BytesTy || typ.T == ethAbi.FixedBytesTy {
-			return string(bytes.Trim(value.([]byte), "\x00")[:]), nil
-		}
-		var val []string
-		if typ.Elem.T == ethAbi.FixedBytesTy {
-			byteVals := reflect.ValueOf(value)
-			for i := 0; i < byteVals.Len(); i++ {
-				val = append(val, string(bytes.Trim(byteVals.Index(i).Interface().([]byte), "\x00")[:]))
+			b := bytesValue(reflect.ValueOf(value))
+			// go-ethereum decodes fixed bytes to the whole word they are padded to
+			if typ.T == ethAbi.FixedBytesTy && typ.SliceSize > 0 && typ.SliceSize < len(b) {
+				b = b[:typ.SliceSize]
 			}
-		} else {
-			values := reflect.ValueOf(value)
-			for i := 0; i < typ.SliceSize; i++ {
-				underlyingValue, err := getStringValue(values.Index(i).Interface(), *typ.Elem)
-				if err != nil {
-					return "", err
-				}
-				val = append(val, underlyingValue)
+			return formatBytes(b, encoding), nil
+		}
+		// arrays of arrays are only decoded by go-ethereum for the arguments of events
+		values := reflect.ValueOf(value)
+		if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+			return "", fmt.Errorf("Could not unpack array %v", value)
+		}
+		val := make([]string, values.Len())
+		for i := range val {
+			var err error
+			if val[i], err = getStringValue(values.Index(i).Interface(), *typ.Elem, encoding); err != nil {
+				return "", err
 			}
 		}
-		StringVal := strings.Join(val, ",")
-		StringVal = strings.Join([]string{"[", StringVal, "]"}, "")
-		return StringVal, nil
+		return "[" + strings.Join(val, ",") + "]", nil
 	} else {
 		switch typ.T {
 		case ethAbi.IntTy:
@@ -445,3 +385,13 @@ func getStringValue(value interface{}, typ ethAbi.Type) (string, error) {
 		}
 	}
 }
+
+// bytesValue reads bytes decoded as a slice or as an array of fixed length
+func bytesValue(value reflect.Value) []byte {
+	if value.Kind() == reflect.Slice {
+		return value.Bytes()
+	}
+	b := make([]byte, value.Len())
+	reflect.Copy(reflect.ValueOf(b), value)
+	return b
+}
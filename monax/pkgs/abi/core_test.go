@@ -317,7 +317,7 @@ func TestUnpacker(t *testing.T) {
 	} {
 		//t.Log(test.name)
 		t.Log(test.packed)
-		output, err := Unpacker(test.abi, test.name, test.packed)
+		output, err := Unpacker(test.abi, test.name, test.packed, BytesAuto)
 		if err != nil {
 			t.Errorf("Unpacker failed: %v", err)
 		}
@@ -334,3 +334,38 @@ func TestUnpacker(t *testing.T) {
 		}
 	}
 }
+
+func TestPackIntegerFormats(t *testing.T) {
+	for _, typ := range []string{"uint256", "uint8", "int64", "uint256[]"} {
+		abiData := `[{"type":"function","name":"f","inputs":[{"name":"","type":"` + typ + `"}],"outputs":[]}]`
+		arg, other := "200", "0xC8"
+		if typ == "uint256[]" {
+			arg, other = "[200,1_000]", "[0xc8,1e3]"
+		}
+		expected, err := Packer(abiData, "f", arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packed, err := Packer(abiData, "f", other)
+		if err != nil {
+			t.Errorf("unexpected error packing %s as %s: %v", other, typ, err)
+		} else if !bytes.Equal(packed, expected) {
+			t.Errorf("expected %s to be packed as %s to\n%X\ngot\n%X", other, arg, expected, packed)
+		}
+		for _, value := range []string{"2_00", "2e2", "20_000e-2"} {
+			if typ == "uint256[]" {
+				value = "[" + value + ",1e3]"
+			}
+			if packed, err := Packer(abiData, "f", value); err != nil || !bytes.Equal(packed, expected) {
+				t.Errorf("expected %s to be packed as %s: %v", value, arg, err)
+			}
+		}
+	}
+
+	abiData := `[{"type":"function","name":"f","inputs":[{"name":"","type":"uint8"}],"outputs":[]}]`
+	for _, value := range []string{"1e3", "2.5", "0x", "5 token"} {
+		if _, err := Packer(abiData, "f", value); err == nil {
+			t.Errorf("expected an error packing %s as uint8", value)
+		}
+	}
+}
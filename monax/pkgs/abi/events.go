@@ -0,0 +1,119 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// the name under which the arguments of an event are temporarily registered as the outputs of a
+// method so that go-ethereum's unpacking can be reused for logs
+const eventMethodName = "__event__"
+
+// UnpackEvent finds the event of the abi which emitted the log (by matching the first topic against
+// the event signatures) and decodes its arguments. Indexed arguments are read from the remaining
+// topics; indexed strings, bytes and arrays are only stored as their keccak256 hash so those are
+// returned as hex. The name of the event is returned along with one variable per argument, bytes
+// being in the encoding.
+func UnpackEvent(abiData string, topics [][]byte, data []byte, encoding string) (string, []*definitions.Variable, error) {
+	abiSpec, err := MakeAbi(abiData)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(topics) == 0 {
+		return "", nil, fmt.Errorf("log has no topics, anonymous events cannot be decoded")
+	}
+
+	var event ethAbi.Event
+	var found bool
+	for _, e := range abiSpec.Events {
+		if !e.Anonymous && bytes.Equal(e.Id().Bytes(), topics[0]) {
+			event, found = e, true
+			break
+		}
+	}
+	if !found {
+		return "", nil, fmt.Errorf("no event in abi matches topic %X", topics[0])
+	}
+
+	// the positions of the arguments in the event, as arguments need not be named
+	var indexed, unindexed []ethAbi.Argument
+	var indexedAt, unindexedAt []int
+	for i, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+			indexedAt = append(indexedAt, i)
+		} else {
+			unindexed = append(unindexed, input)
+			unindexedAt = append(unindexedAt, i)
+		}
+	}
+	if len(indexed) != len(topics)-1 {
+		return "", nil, fmt.Errorf("event %s has %d indexed arguments but the log has %d topics", event.Name,
+			len(indexed), len(topics)-1)
+	}
+
+	values := make([]string, len(event.Inputs))
+	for i, input := range indexed {
+		if isHashedTopic(input.Type) {
+			values[indexedAt[i]] = strings.ToUpper(common.Bytes2Hex(topics[i+1]))
+			continue
+		}
+		vars, err := unpackArguments(abiSpec, []ethAbi.Argument{input}, topics[i+1], encoding)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not decode indexed argument %s of event %s: %v", input.Name, event.Name, err)
+		}
+		values[indexedAt[i]] = vars[0].Value
+	}
+	if len(unindexed) != 0 {
+		vars, err := unpackArguments(abiSpec, unindexed, data, encoding)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not decode data of event %s: %v", event.Name, err)
+		}
+		for i := range unindexed {
+			values[unindexedAt[i]] = vars[i].Value
+		}
+	}
+
+	// keep the order of the event definition
+	var eventVars []*definitions.Variable
+	for i, input := range event.Inputs {
+		name := input.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		eventVars = append(eventVars, &definitions.Variable{
+			Name:  name,
+			Value: values[i],
+		})
+	}
+	return event.Name, eventVars, nil
+}
+
+// unpackArguments decodes data as if it were the return of a method with the given outputs
+func unpackArguments(abiSpec ethAbi.ABI, outputs []ethAbi.Argument, data []byte, encoding string) ([]*definitions.Variable, error) {
+	methods := make(map[string]ethAbi.Method, len(abiSpec.Methods)+1)
+	for name, method := range abiSpec.Methods {
+		methods[name] = method
+	}
+	methods[eventMethodName] = ethAbi.Method{Name: eventMethodName, Const: true, Outputs: outputs}
+	abiSpec.Methods = methods
+	return unpackMethod(abiSpec, eventMethodName, data, encoding)
+}
+
+// isHashedTopic reports whether an indexed argument of this type is stored as its hash
+func isHashedTopic(typ ethAbi.Type) bool {
+	switch typ.T {
+	case ethAbi.StringTy, ethAbi.BytesTy:
+		return true
+	default:
+		// the type flags of go-ethereum do not tell bytes32 apart from bytes32[] so use the signature
+		return strings.Contains(typ.String(), "[")
+	}
+}
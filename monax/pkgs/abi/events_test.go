@@ -0,0 +1,86 @@
+package abi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	pm "github.com/monax/bosmarmot/monax/definitions"
+)
+
+const eventsABI = `[
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"label","type":"string"},{"indexed":false,"name":"","type":"string"},{"indexed":false,"name":"flag","type":"bool"}],"name":"Labelled","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"","type":"uint256"},{"indexed":false,"name":"","type":"uint256"},{"indexed":false,"name":"","type":"uint256"}],"name":"Pair","type":"event"}
+]`
+
+func TestUnpackEvent(t *testing.T) {
+	from := common.HexToAddress("0x1040e6521541daa7e2d8b0b8d68a46d9c8a7e0b5")
+	to := common.HexToAddress("0x58fd1799aa32ded3f6eac096a1dc77834a446b9c")
+
+	name, vars, err := UnpackEvent(eventsABI,
+		[][]byte{
+			crypto.Keccak256([]byte("Transfer(address,address,uint256)")),
+			pad(from.Bytes(), 32, true),
+			pad(to.Bytes(), 32, true),
+		},
+		pad([]byte{100}, 32, true), BytesAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "Transfer" {
+		t.Errorf("expected event Transfer, got %s", name)
+	}
+	expected := [][2]string{
+		{"from", "1040E6521541DAA7E2D8B0B8D68A46D9C8A7E0B5"},
+		{"to", "58FD1799AA32DED3F6EAC096A1DC77834A446B9C"},
+		{"value", "100"},
+	}
+	checkVars(t, vars, expected)
+
+	labelHash := crypto.Keccak256([]byte("marmot"))
+	data := append(pad([]byte{64}, 32, true), pad([]byte{1}, 32, true)...)
+	data = append(data, pad([]byte{5}, 32, true)...)
+	data = append(data, pad([]byte("hello"), 32, false)...)
+	name, vars, err = UnpackEvent(eventsABI,
+		[][]byte{crypto.Keccak256([]byte("Labelled(string,string,bool)")), labelHash}, data, BytesAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "Labelled" {
+		t.Errorf("expected event Labelled, got %s", name)
+	}
+	checkVars(t, vars, [][2]string{
+		{"label", strings.ToUpper(common.Bytes2Hex(labelHash))},
+		{"1", "hello"},
+		{"flag", "true"},
+	})
+
+	// unnamed arguments are told apart by their position
+	_, vars, err = UnpackEvent(eventsABI,
+		[][]byte{crypto.Keccak256([]byte("Pair(uint256,uint256,uint256)")), pad([]byte{1}, 32, true)},
+		append(pad([]byte{2}, 32, true), pad([]byte{3}, 32, true)...), BytesAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkVars(t, vars, [][2]string{{"0", "1"}, {"1", "2"}, {"2", "3"}})
+
+	if _, _, err := UnpackEvent(eventsABI, [][]byte{crypto.Keccak256([]byte("Approval(address,address,uint256)"))}, nil, BytesAuto); err == nil {
+		t.Errorf("expected an error for a log which matches no event")
+	}
+	if _, _, err := UnpackEvent(eventsABI, nil, nil, BytesAuto); err == nil {
+		t.Errorf("expected an error for a log without topics")
+	}
+}
+
+func checkVars(t *testing.T, vars []*pm.Variable, expected [][2]string) {
+	if len(vars) != len(expected) {
+		t.Fatalf("expected %d variables, got %d", len(expected), len(vars))
+	}
+	for i, v := range vars {
+		if v.Name != expected[i][0] || v.Value != expected[i][1] {
+			t.Errorf("expected variable %s=%s, got %s=%s", expected[i][0], expected[i][1], v.Name, v.Value)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// the selector solidity prefixes the message of require(cond, "message") and revert("message") with
+var errorStringSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+var stringType, _ = ethAbi.NewType("string")
+
+// UnpackRevert decodes the data returned by a reverted transaction into a readable reason. Data
+// from require/revert with a message gives that message, data from a custom error declared in the
+// abi gives the error with its arguments, e.g. InsufficientBalance(available: 10, required: 20).
+// abiData may be empty in which case only messages can be decoded.
+func UnpackRevert(abiData string, data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", fmt.Errorf("revert data too short to hold a selector")
+	}
+	selector, args := data[:4], data[4:]
+
+	if bytes.Equal(selector, errorStringSelector) {
+		vars, err := unpackArguments(ethAbi.ABI{}, []ethAbi.Argument{{Type: stringType}}, args, BytesAuto)
+		if err != nil {
+			return "", fmt.Errorf("could not decode revert message: %v", err)
+		}
+		return vars[0].Value, nil
+	}
+
+	if abiData == "" {
+		return "", fmt.Errorf("no abi to decode revert selector %X with", selector)
+	}
+	// only the errors are parsed, custom errors being declared as `error Name(args)` in solidity and
+	// decoded with the codec since go-ethereum skips them and cannot decode structs. An error with
+	// types which cannot be read is skipped rather than failing the others
+	var entries []json.RawMessage
+	if err := json.Unmarshal([]byte(abiData), &entries); err != nil {
+		return "", err
+	}
+	for _, raw := range entries {
+		var entry Function
+		if err := json.Unmarshal(raw, &entry); err != nil || entry.Type != "error" {
+			continue
+		}
+		signature, err := entry.Signature()
+		if err != nil || !bytes.Equal(crypto.Keccak256([]byte(signature))[:4], selector) {
+			continue
+		}
+		if len(entry.Inputs) == 0 {
+			return entry.Name + "()", nil
+		}
+		vars, err := Function{Name: entry.Name, Outputs: entry.Inputs}.Unpack(args, BytesAuto)
+		if err != nil {
+			return "", fmt.Errorf("could not decode arguments of error %s: %v", entry.Name, err)
+		}
+		formatted := make([]string, len(entry.Inputs))
+		for i, input := range entry.Inputs {
+			// the variables of the inputs come before those of the fields of structs
+			if input.Name != "" {
+				formatted[i] = fmt.Sprintf("%s: %s", input.Name, vars[i].Value)
+			} else {
+				formatted[i] = vars[i].Value
+			}
+		}
+		return fmt.Sprintf("%s(%s)", entry.Name, strings.Join(formatted, ", ")), nil
+	}
+	return "", fmt.Errorf("no error in abi matches revert selector %X", selector)
+}
@@ -0,0 +1,60 @@
+package abi
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const revertABI = `[
+	{"inputs":[{"name":"order","type":"tuple","components":[{"name":"id","type":"uint256"},{"name":"open","type":"bool"}]}],"name":"BadOrder","type":"error"},
+	{"inputs":[{"name":"","type":"marmot"}],"name":"Broken","type":"error"},
+	{"inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"},
+	{"inputs":[],"name":"Unauthorized","type":"error"},
+	{"constant":false,"inputs":[{"name":"amount","type":"uint256"}],"name":"withdraw","outputs":[],"type":"function"}
+]`
+
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+func TestUnpackRevert(t *testing.T) {
+	message := append(selector("Error(string)"), pad([]byte{32}, 32, true)...)
+	message = append(message, pad([]byte{18}, 32, true)...)
+	message = append(message, pad([]byte("not enough marmots"), 32, false)...)
+
+	insufficient := append(selector("InsufficientBalance(uint256,uint256)"), pad([]byte{10}, 32, true)...)
+	insufficient = append(insufficient, pad([]byte{20}, 32, true)...)
+
+	badOrder := append(selector("BadOrder((uint256,bool))"), pad([]byte{7}, 32, true)...)
+	badOrder = append(badOrder, pad([]byte{1}, 32, true)...)
+
+	for _, test := range []struct {
+		abi    string
+		data   []byte
+		reason string
+		err    bool
+	}{
+		{"", message, "not enough marmots", false},
+		{revertABI, message, "not enough marmots", false},
+		{revertABI, insufficient, "InsufficientBalance(available: 10, required: 20)", false},
+		{revertABI, selector("Unauthorized()"), "Unauthorized()", false},
+		{revertABI, badOrder, "BadOrder(order: (7,true))", false},
+		{"", insufficient, "", true},
+		{revertABI, selector("Unknown()"), "", true},
+		{revertABI, []byte{0x01}, "", true},
+	} {
+		reason, err := UnpackRevert(test.abi, test.data)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error decoding %X, got %s", test.data, reason)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error decoding %X: %v", test.data, err)
+		} else if reason != test.reason {
+			t.Errorf("expected reason %q, got %q", test.reason, reason)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package abi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ParseSignature reads a function or event signature such as transfer(address,uint) into a function,
+// the parameters may be named and tuples are given as (type,type)
+func ParseSignature(signature string) (Function, error) {
+	signature = strings.TrimSpace(signature)
+	i := strings.Index(signature, "(")
+	if i < 1 {
+		return Function{}, fmt.Errorf("expected a signature of the form name(type,...), got %s", signature)
+	}
+	params, err := splitList(signature[i:], '(', ')')
+	if err != nil {
+		return Function{}, fmt.Errorf("could not read the parameters of %s: %v", signature, err)
+	}
+	fn := Function{Type: "function", Name: strings.TrimSpace(signature[:i])}
+	for _, param := range params {
+		arg, err := parseParameter(param)
+		if err != nil {
+			return Function{}, fmt.Errorf("could not read the parameters of %s: %v", signature, err)
+		}
+		fn.Inputs = append(fn.Inputs, arg)
+	}
+	// check the types
+	if _, err := fn.Signature(); err != nil {
+		return Function{}, err
+	}
+	return fn, nil
+}
+
+// signature is the signature of a function taking arguments of the canonical types, such as
+// transfer(address,uint256)
+func signature(name string, types []string) string {
+	return name + "(" + strings.Join(types, ",") + ")"
+}
+
+// parseParameter reads a type optionally followed by indexed and a name
+func parseParameter(param string) (Argument, error) {
+	var arg Argument
+	var rest string
+	if strings.HasPrefix(param, "(") {
+		end := closingParen(param)
+		if end < 0 {
+			return arg, fmt.Errorf("unbalanced brackets in %s", param)
+		}
+		fields, err := splitList(param[:end+1], '(', ')')
+		if err != nil {
+			return arg, err
+		}
+		for _, field := range fields {
+			component, err := parseParameter(field)
+			if err != nil {
+				return arg, err
+			}
+			arg.Components = append(arg.Components, component)
+		}
+		rest = param[end+1:]
+		suffix := rest
+		if i := strings.IndexAny(rest, " \t"); i >= 0 {
+			suffix = rest[:i]
+		}
+		arg.Type = "tuple" + suffix
+		rest = rest[len(suffix):]
+	} else {
+		words := strings.Fields(param)
+		if len(words) == 0 {
+			return arg, fmt.Errorf("missing type")
+		}
+		arg.Type = words[0]
+		rest = strings.TrimPrefix(strings.TrimSpace(param), words[0])
+	}
+	words := strings.Fields(rest)
+	if len(words) > 0 && words[0] == "indexed" {
+		arg.Indexed = true
+		words = words[1:]
+	}
+	switch len(words) {
+	case 0:
+	case 1:
+		arg.Name = words[0]
+	default:
+		return arg, fmt.Errorf("unexpected %s after the type %s", strings.Join(words, " "), arg.Type)
+	}
+	return arg, nil
+}
+
+func closingParen(value string) int {
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Selector is the first four bytes of the hash of the canonical signature, which calls to the
+// function start with
+func Selector(signature string) ([]byte, error) {
+	topic, err := Topic(signature)
+	if err != nil {
+		return nil, err
+	}
+	return topic[:4], nil
+}
+
+// Topic is the hash of the canonical signature, the first topic of the logs of an event
+func Topic(signature string) ([]byte, error) {
+	fn, err := ParseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := fn.Signature()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte(canonical)), nil
+}
@@ -0,0 +1,106 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseSignature(t *testing.T) {
+	for _, test := range []struct {
+		signature, canonical string
+	}{
+		{"transfer(address,uint)", "transfer(address,uint256)"},
+		{" transfer( address to , uint256 amount ) ", "transfer(address,uint256)"},
+		{"Transfer(address indexed from, address indexed to, uint value)", "Transfer(address,address,uint256)"},
+		{"f()", "f()"},
+		{"place((address owner,uint[] prices)[2][] orders,int8)", "place((address,uint256[])[2][],int8)"},
+		{"g(((bool,string),bytes32))", "g(((bool,string),bytes32))"},
+	} {
+		fn, err := ParseSignature(test.signature)
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %v", test.signature, err)
+			continue
+		}
+		if canonical, _ := fn.Signature(); canonical != test.canonical {
+			t.Errorf("expected %s to be read as %s, got %s", test.signature, test.canonical, canonical)
+		}
+	}
+
+	fn, _ := ParseSignature("Transfer(address indexed from, (uint a) amount)")
+	if !fn.Inputs[0].Indexed || fn.Inputs[0].Name != "from" || fn.Inputs[1].Name != "amount" ||
+		fn.Inputs[1].Components[0].Name != "a" {
+		t.Errorf("unexpected parameters %+v", fn.Inputs)
+	}
+
+	for _, signature := range []string{"transfer", "(address)", "transfer(address", "f(marmot)", "f(uint a b)",
+		"f((uint,bool)"} {
+		if _, err := ParseSignature(signature); err == nil {
+			t.Errorf("expected an error parsing %s", signature)
+		}
+	}
+}
+
+func TestSelectorAndTopic(t *testing.T) {
+	selector, err := Selector("transfer(address,uint)")
+	if err != nil || fmt.Sprintf("%x", selector) != "a9059cbb" {
+		t.Errorf("unexpected selector %x: %v", selector, err)
+	}
+	topic, err := Topic("Transfer(address indexed from, address indexed to, uint256 value)")
+	if err != nil || fmt.Sprintf("%x", topic) != "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" {
+		t.Errorf("unexpected topic %x: %v", topic, err)
+	}
+}
+
+const overloadedABI = `[
+{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],
+	"outputs":[{"name":"","type":"bool"}]},
+{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"},
+	{"name":"memo","type":"bytes"}],"outputs":[{"name":"","type":"bool"},{"name":"id","type":"uint64"}]},
+{"type":"function","name":"balance","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+func TestOverloads(t *testing.T) {
+	const to = "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7"
+	for _, test := range []struct {
+		function string
+		args     []string
+		selector string
+	}{
+		{"transfer(address,uint)", []string{to, "5"}, "a9059cbb"},
+		{"transfer(address to, uint256 amount, bytes memo)", []string{to, "5", "0x01"}, "be45fd62"},
+		{"balance", []string{to}, "e3d670d7"},
+		{"balance(address)", []string{to}, "e3d670d7"},
+	} {
+		packed, err := Packer(overloadedABI, test.function, test.args...)
+		if err != nil {
+			t.Errorf("unexpected error packing %s: %v", test.function, err)
+			continue
+		}
+		if selector := fmt.Sprintf("%x", packed[:4]); selector != test.selector {
+			t.Errorf("expected %s to be packed with the selector %s, got %s", test.function, test.selector, selector)
+		}
+	}
+
+	packed, _ := Packer(overloadedABI, "balance", to)
+	if bySignature, _ := Packer(overloadedABI, "balance(address)", to); !bytes.Equal(packed, bySignature) {
+		t.Errorf("expected a function given by signature to be packed as by name\n%X\n%X", packed, bySignature)
+	}
+
+	vars, err := Unpacker(overloadedABI, "transfer(address,uint256,bytes)", append(word(1), word(7)...), BytesAuto)
+	if err != nil || len(vars) != 2 || vars[0].Value != "true" || vars[1].Name != "id" || vars[1].Value != "7" {
+		t.Errorf("unexpected return of an overload %v: %v", vars, err)
+	}
+
+	_, err = Packer(overloadedABI, "transfer", to, "5")
+	if err == nil || !strings.Contains(err.Error(), "transfer(address,uint256), transfer(address,uint256,bytes)") {
+		t.Errorf("expected an error listing the overloads of transfer, got %v", err)
+	}
+	if _, err = Unpacker(overloadedABI, "transfer", word(1), BytesAuto); err == nil {
+		t.Errorf("expected an error unpacking the return of an overloaded function by name")
+	}
+	if _, err = Packer(overloadedABI, "transfer(bool)", "true"); err == nil {
+		t.Errorf("expected an error packing a signature not in the abi")
+	}
+}
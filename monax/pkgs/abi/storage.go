@@ -0,0 +1,94 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// StorageKey is the key of a slot of the storage of a contract or, given the key of a mapping at the
+// slot, the key of its entry, keccak256(key . slot). Keys of value types are padded to a word as in
+// calls, while string and bytes keys are hashed as they are
+func StorageKey(slot, keyType, key string) ([]byte, error) {
+	n, err := util.ParseInteger(slot, nil)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("slot %s is out of range", slot)
+	}
+	slotWord := math.PaddedBigBytes(n, 32)
+	if keyType == "" {
+		return slotWord, nil
+	}
+
+	t, err := newType(Argument{Type: keyType})
+	if err != nil {
+		return nil, err
+	}
+	var packed []byte
+	switch t.kind {
+	case "string":
+		packed = []byte(key)
+	case "bytes":
+		if packed, err = parseBytes(key); err != nil {
+			return nil, err
+		}
+	case "array", "tuple":
+		return nil, fmt.Errorf("mappings cannot have keys of type %s", t)
+	default:
+		if packed, err = t.encode(key); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.Keccak256(packed, slotWord), nil
+}
+
+// DecodeStorage decodes a value of a type held in a word of storage. Values smaller than a word may
+// be packed with others, so the value is read from offset bytes above the low order end of the word
+func DecodeStorage(typ string, word []byte, offset int, encoding string) (string, error) {
+	t, err := newType(Argument{Type: typ})
+	if err != nil {
+		return "", err
+	}
+	var size int
+	switch t.kind {
+	case "uint", "int":
+		size = t.size / 8
+	case "fixedbytes":
+		size = t.size
+	case "address":
+		size = 20
+	case "bool":
+		size = 1
+	default:
+		return "", fmt.Errorf("only types which fit in a word can be read from storage, not %s", t)
+	}
+	if offset < 0 || offset+size > 32 {
+		return "", fmt.Errorf("%s at offset %d does not fit in a word", t, offset)
+	}
+
+	if len(word) > 32 {
+		return "", fmt.Errorf("storage holds words of 32 bytes, got %d", len(word))
+	}
+	word = common.LeftPadBytes(word, 32)
+	value := word[32-offset-size : 32-offset]
+	switch {
+	case t.kind == "fixedbytes":
+		word = common.RightPadBytes(value, 32)
+	case t.kind == "int" && value[0]&0x80 != 0:
+		// sign extend
+		word = append(bytes.Repeat([]byte{0xff}, 32-size), value...)
+	default:
+		word = common.LeftPadBytes(value, 32)
+	}
+	decoded, err := t.decode(word, encoding)
+	if err != nil {
+		return "", err
+	}
+	return decoded.(string), nil
+}
@@ -0,0 +1,88 @@
+package abi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestStorageKey(t *testing.T) {
+	const address = "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7"
+	for _, test := range []struct {
+		slot, keyType, key string
+		expected           []byte
+	}{
+		{"3", "", "", word(3)},
+		{"0x10", "", "", word(16)},
+		{"0", "uint256", "0", common.Hex2Bytes("ad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5")},
+		{"1", "address", address, crypto.Keccak256(common.LeftPadBytes(common.Hex2Bytes(address), 32), word(1))},
+		{"2", "string", "marmot", crypto.Keccak256([]byte("marmot"), word(2))},
+		{"2", "bytes", "0xabcd", crypto.Keccak256([]byte{0xab, 0xcd}, word(2))},
+		{"2", "bytes32", "0xabcd", crypto.Keccak256(common.RightPadBytes([]byte{0xab, 0xcd}, 32), word(2))},
+	} {
+		key, err := StorageKey(test.slot, test.keyType, test.key)
+		if err != nil {
+			t.Errorf("unexpected error computing the key of slot %s: %v", test.slot, err)
+		} else if !bytes.Equal(key, test.expected) {
+			t.Errorf("expected the key of slot %s with %s %s to be %x, got %x", test.slot, test.keyType, test.key,
+				test.expected, key)
+		}
+	}
+
+	for _, test := range [][3]string{
+		{"-1", "", ""},
+		{"0x1" + strings.Repeat("0", 64), "", ""},
+		{"slot", "", ""},
+		{"0", "uint8", "256"},
+		{"0", "uint[]", "[1]"},
+		{"0", "marmot", "1"},
+	} {
+		if _, err := StorageKey(test[0], test[1], test[2]); err == nil {
+			t.Errorf("expected an error computing the key of %v", test)
+		}
+	}
+}
+
+func TestDecodeStorage(t *testing.T) {
+	// a bool, an int8 of -2 and a uint16 of 500 packed into a word after an address
+	packed := common.Hex2Bytes("01" + "fe" + "01f4" + "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7")
+	for _, test := range []struct {
+		typ      string
+		word     []byte
+		offset   int
+		expected string
+	}{
+		{"address", packed, 0, "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7"},
+		{"uint16", packed, 20, "500"},
+		{"int8", packed, 22, "-2"},
+		{"bool", packed, 23, "true"},
+		{"uint256", word(7), 0, "7"},
+		{"int256", bytes.Repeat([]byte{0xff}, 32), 0, "-1"},
+		{"bytes2", []byte{0xab, 0xcd}, 0, "0xABCD"},
+		{"uint", nil, 0, "0"},
+	} {
+		value, err := DecodeStorage(test.typ, test.word, test.offset, BytesHex)
+		if err != nil || value != test.expected {
+			t.Errorf("expected %s at offset %d to be %s, got %s: %v", test.typ, test.offset, test.expected, value, err)
+		}
+	}
+
+	for _, test := range []struct {
+		typ    string
+		offset int
+		word   []byte
+	}{
+		{"string", 0, nil},
+		{"uint[2]", 0, nil},
+		{"address", 13, nil},
+		{"uint8", -1, nil},
+		{"uint8", 0, make([]byte, 33)},
+	} {
+		if _, err := DecodeStorage(test.typ, test.word, test.offset, BytesHex); err == nil {
+			t.Errorf("expected an error decoding %s at offset %d", test.typ, test.offset)
+		}
+	}
+}
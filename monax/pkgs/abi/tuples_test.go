@@ -0,0 +1,168 @@
+package abi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	pm "github.com/monax/bosmarmot/monax/definitions"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const tupleABI = `[
+{"type":"function","name":"set","inputs":[{"name":"item","type":"tuple","components":[
+	{"name":"id","type":"uint256"},{"name":"label","type":"string"}]}],"outputs":[]},
+{"type":"function","name":"get","inputs":[],"outputs":[
+	{"name":"item","type":"tuple","components":[{"name":"id","type":"uint256"},{"name":"label","type":"string"}]},
+	{"name":"orders","type":"tuple[]","components":[{"name":"owner","type":"address"},{"name":"prices","type":"int16[]"}]},
+	{"name":"","type":"bool"}]},
+{"type":"function","name":"plain","inputs":[{"name":"x","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"event","name":"Set","inputs":[{"name":"item","type":"tuple","indexed":false,"components":[{"name":"id","type":"uint256"}]}]}
+]`
+
+func word(n byte) []byte {
+	return pad([]byte{n}, 32, true)
+}
+
+func TestTupleSignature(t *testing.T) {
+	functions, err := ReadFunctions(tupleABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"set((uint256,string))", "get()", "plain(uint256)", "Set((uint256))"} {
+		if signature, err := functions[i].Signature(); err != nil || signature != expected {
+			t.Errorf("expected signature %s, got %s: %v", expected, signature, err)
+		}
+	}
+	fn := Function{Name: "place", Inputs: []Argument{{Type: "tuple[2][]", Components: []Argument{
+		{Type: "address"}, {Type: "uint[]"}, {Type: "bytes32"}}}}}
+	if signature, _ := fn.Signature(); signature != "place((address,uint256[],bytes32)[2][])" {
+		t.Errorf("unexpected signature of nested tuple arrays %s", signature)
+	}
+}
+
+func TestPackTuple(t *testing.T) {
+	packed, err := Packer(tupleABI, "set", "(1,marmots)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []byte
+	expected = append(expected, crypto.Keccak256([]byte("set((uint256,string))"))[:4]...)
+	expected = append(expected, word(0x20)...)
+	expected = append(expected, word(1)...)
+	expected = append(expected, word(0x40)...)
+	expected = append(expected, word(7)...)
+	expected = append(expected, pad([]byte("marmots"), 32, false)...)
+	if !bytes.Equal(packed, expected) {
+		t.Errorf("expected the tuple to be packed to\n%X\ngot\n%X", expected, packed)
+	}
+
+	// the functions without tuples are still packed by go-ethereum
+	if packed, err := Packer(tupleABI, "plain", "5"); err != nil || !bytes.Equal(packed[4:], word(5)) {
+		t.Errorf("expected to pack a function without tuples from an abi with tuples, got %X: %v", packed, err)
+	}
+
+	for _, args := range [][]string{{"(1)"}, {"1"}, {"(x,marmots)"}, {"(1,marmots"}} {
+		if _, err := Packer(tupleABI, "set", args...); err == nil {
+			t.Errorf("expected an error packing %v", args)
+		}
+	}
+}
+
+func TestUnpackInput(t *testing.T) {
+	packed, err := Packer(tupleABI, "set", "(1,marmots)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars, err := UnpackInput(tupleABI, "set", packed, BytesAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*pm.Variable{
+		{Name: "item", Value: "(1,marmots)"},
+		{Name: "item.id", Value: "1"},
+		{Name: "item.label", Value: "marmots"},
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("unexpected variables unpacking the input of set %v", vars)
+	}
+	if _, err := UnpackInput(tupleABI, "plain", packed, BytesAuto); err == nil {
+		t.Errorf("expected an error unpacking the input of set as plain")
+	}
+}
+
+// the elementary types are packed as geth packs them, arrays are compared in arrays_test.go
+func TestPackLikeGeth(t *testing.T) {
+	for _, test := range []struct {
+		inputs string
+		args   []string
+	}{
+		{`[{"name":"","type":"uint256"},{"name":"","type":"string"},{"name":"","type":"address"},{"name":"","type":"int8"},
+			{"name":"","type":"bytes32"},{"name":"","type":"bytes"}]`,
+			[]string{"1000", "marmots in the den", "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7", "-5", "marmatoshi", "den"}},
+	} {
+		abiData := `[{"type":"function","name":"f","inputs":` + test.inputs + `,"outputs":[]}]`
+		expected, err := Packer(abiData, "f", test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		functions, err := ReadFunctions(abiData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packed, err := functions[0].Pack(test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(packed, expected) {
+			t.Errorf("expected %v to be packed as geth packs them\n%X\ngot\n%X", test.args, expected, packed)
+		}
+	}
+}
+
+func TestUnpackTuples(t *testing.T) {
+	functions, err := ReadFunctions(tupleABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := functions[1]
+	// the outputs of get packed as if they were the inputs of a function
+	outputs := Function{Type: "constructor", Inputs: get.Outputs}
+	data, err := outputs.Pack("(7,marmots)",
+		"[(1040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[1,-2]),(0x2040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[])]", "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars, err := Unpacker(tupleABI, "get", data, BytesAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*pm.Variable{
+		{Name: "item", Value: "(7,marmots)"},
+		{Name: "orders", Value: "[(1040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[1,-2]),(2040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[])]"},
+		{Name: "2", Value: "true"},
+		{Name: "item.id", Value: "7"},
+		{Name: "item.label", Value: "marmots"},
+		{Name: "orders[0]", Value: "(1040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[1,-2])"},
+		{Name: "orders[0].owner", Value: "1040E6521541DAB4E7EE57F21226DD17CE9F0FB7"},
+		{Name: "orders[0].prices", Value: "[1,-2]"},
+		{Name: "orders[1]", Value: "(2040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[])"},
+		{Name: "orders[1].owner", Value: "2040E6521541DAB4E7EE57F21226DD17CE9F0FB7"},
+		{Name: "orders[1].prices", Value: "[]"},
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		for _, v := range vars {
+			t.Logf("%s = %s", v.Name, v.Value)
+		}
+		t.Errorf("unexpected variables unpacking tuples")
+	}
+
+	if _, err := Unpacker(tupleABI, "get", data[:100], BytesAuto); err == nil {
+		t.Errorf("expected an error unpacking truncated data")
+	}
+	if _, err := Unpacker(tupleABI, "get", append(common.LeftPadBytes([]byte{0xff, 0xff}, 32), data[32:]...), BytesAuto); err == nil {
+		t.Errorf("expected an error unpacking an offset beyond the data")
+	}
+}
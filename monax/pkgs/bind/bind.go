@@ -0,0 +1,350 @@
+// Package bind is what the go bindings generated by bos abi gen-go deploy, transact with and query
+// contracts through. Arguments are packed and returns and logs unpacked by monax/pkgs/abi, so the
+// bindings support the types the package jobs do.
+package bind
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/execution/evm/events"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// Session is the chain the bindings send transactions to and query, with the account transactions
+// are signed by and the amount, fee and gas they are sent with
+type Session struct {
+	Node client.NodeClient
+	Keys keys.KeyClient
+	// id of the chain transactions are signed for, looked up from the node when empty
+	ChainID string
+	// account signing transactions, which queries are made from
+	Source acm.Address
+	// public key of the source, when the keys client does not hold it
+	PublicKey string
+
+	Amount string
+	Fee    string
+	Gas    string
+}
+
+// NewSession returns a session sending transactions from the source with the default amount, fee
+// and gas of the package jobs
+func NewSession(node client.NodeClient, keyClient keys.KeyClient, source acm.Address) *Session {
+	return &Session{
+		Node:   node,
+		Keys:   keyClient,
+		Source: source,
+		Amount: "0",
+		Fee:    "9999",
+		Gas:    "1111111111",
+	}
+}
+
+// Contract is a deployed contract with its abi
+type Contract struct {
+	ABI     string
+	Address acm.Address
+	Session *Session
+}
+
+// NewContract binds the contract deployed at the address
+func NewContract(abiData string, address acm.Address, session *Session) *Contract {
+	return &Contract{ABI: abiData, Address: address, Session: session}
+}
+
+// Deploy deploys the bytecode with the arguments of the constructor and binds the contract created
+func Deploy(session *Session, abiData, bytecode string, args ...interface{}) (*Contract, *rpc.TxResult, error) {
+	data := strings.TrimPrefix(bytecode, "0x")
+	if len(args) > 0 {
+		packed, err := pack(abiData, "", args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not pack the arguments of the constructor: %v", err)
+		}
+		data += hex.EncodeToString(packed)
+	}
+	result, err := session.send("", data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.Address == nil {
+		return nil, result, fmt.Errorf("deploying the contract did not create one")
+	}
+	return NewContract(abiData, *result.Address, session), result, nil
+}
+
+// Transact sends a transaction calling the function, given by its signature, and waits for it to be
+// committed
+func (c *Contract) Transact(function string, args ...interface{}) (*rpc.TxResult, error) {
+	packed, err := pack(c.ABI, function, args)
+	if err != nil {
+		return nil, fmt.Errorf("could not pack the arguments of %s: %v", function, err)
+	}
+	return c.Session.send(c.Address.String(), hex.EncodeToString(packed))
+}
+
+// Call queries the function, given by its signature, and decodes what it returns into the results,
+// which are pointers to values of the types of the outputs
+func (c *Contract) Call(function string, args []interface{}, results ...interface{}) error {
+	packed, err := pack(c.ABI, function, args)
+	if err != nil {
+		return fmt.Errorf("could not pack the arguments of %s: %v", function, err)
+	}
+	ret, _, err := c.Session.Node.QueryContract(c.Session.Source, c.Address, packed)
+	if err != nil {
+		return err
+	}
+	vars, err := abi.Unpacker(c.ABI, function, ret, abi.BytesHex)
+	if err != nil {
+		return fmt.Errorf("could not unpack the return of %s: %v", function, err)
+	}
+	if len(vars) < len(results) {
+		return fmt.Errorf("%s returned %d values, expected %d", function, len(vars), len(results))
+	}
+	for i, result := range results {
+		if err := Decode(vars[i].Value, result); err != nil {
+			return fmt.Errorf("could not decode return %s of %s: %v", vars[i].Name, function, err)
+		}
+	}
+	return nil
+}
+
+// UnpackLog decodes a log of the event into the fields, pointers to values of the types of the
+// arguments of the event in order. Indexed strings, bytes and arrays are only logged as their hash,
+// which is decoded into bytes
+func (c *Contract) UnpackLog(event string, log *events.EventDataLog, fields ...interface{}) error {
+	topics := make([][]byte, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Bytes()
+	}
+	name, vars, err := abi.UnpackEvent(c.ABI, topics, log.Data, abi.BytesHex)
+	if err != nil {
+		return err
+	}
+	if name != event {
+		return fmt.Errorf("log is of event %s, not %s", name, event)
+	}
+	if len(vars) != len(fields) {
+		return fmt.Errorf("event %s has %d arguments, expected %d", event, len(vars), len(fields))
+	}
+	for i, field := range fields {
+		if err := Decode(vars[i].Value, field); err != nil {
+			return fmt.Errorf("could not decode argument %s of event %s: %v", vars[i].Name, event, err)
+		}
+	}
+	return nil
+}
+
+// chainIdentifier is the burrow node client, which looks up the id of its chain
+type chainIdentifier interface {
+	ChainId() (chainName, chainID string, genesisHash []byte, err error)
+}
+
+// send signs and broadcasts a call, or a deploy when there is no destination, and waits for it
+func (s *Session) send(destination, data string) (*rpc.TxResult, error) {
+	tx, err := rpc.Call(s.Node, s.Keys, s.PublicKey, s.Source.String(), destination, s.Amount, "", s.Gas,
+		s.Fee, data)
+	if err != nil {
+		return nil, err
+	}
+	if s.ChainID == "" {
+		node, ok := s.Node.(chainIdentifier)
+		if !ok {
+			return nil, fmt.Errorf("the chain id of the session must be given for a node client which cannot look it up")
+		}
+		if _, s.ChainID, _, err = node.ChainId(); err != nil {
+			return nil, err
+		}
+	}
+	result, err := rpc.SignAndBroadcast(s.ChainID, s.Node, s.Keys, tx, true, true, true)
+	if err != nil {
+		return nil, err
+	}
+	if result.Exception != "" {
+		return result, fmt.Errorf("transaction failed: %s", result.Exception)
+	}
+	return result, nil
+}
+
+func pack(abiData, function string, args []interface{}) ([]byte, error) {
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		var err error
+		if formatted[i], err = Format(arg); err != nil {
+			return nil, err
+		}
+	}
+	return abi.Packer(abiData, function, formatted...)
+}
+
+var bigIntType = reflect.TypeOf(big.Int{})
+var addressType = reflect.TypeOf(acm.Address{})
+
+// Format writes a value in the string form the abi packer takes: integers in decimal, addresses and
+// bytes in hex, slices as [a,b] and structs as (a,b) with their fields in order and strings within
+// them quoted as needed
+func Format(value interface{}) (string, error) {
+	return formatValue(reflect.ValueOf(value))
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", fmt.Errorf("cannot pack nil")
+	}
+	switch {
+	case v.Type() == addressType:
+		return v.Interface().(acm.Address).String(), nil
+	case v.Kind() == reflect.Ptr && v.Type().Elem() == bigIntType:
+		if v.IsNil() {
+			return "", fmt.Errorf("cannot pack a nil integer")
+		}
+		return v.Interface().(*big.Int).String(), nil
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return "0x" + hex.EncodeToString(b), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Slice, reflect.Array, reflect.Struct:
+		n, open, close := v.Len, "[", "]"
+		element := v.Index
+		if v.Kind() == reflect.Struct {
+			n, open, close, element = v.NumField, "(", ")", v.Field
+		}
+		elements := make([]string, n())
+		for i := range elements {
+			var err error
+			if elements[i], err = formatValue(element(i)); err != nil {
+				return "", err
+			}
+			if reflect.Indirect(element(i)).Kind() == reflect.String {
+				elements[i] = util.QuoteElement(elements[i])
+			}
+		}
+		return open + strings.Join(elements, ",") + close, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", fmt.Errorf("cannot pack nil")
+		}
+		return formatValue(v.Elem())
+	}
+	return "", fmt.Errorf("cannot pack a value of type %s", v.Type())
+}
+
+// Decode reads a value in the string form the abi unpacker returns into the pointer: integers into a
+// *big.Int or a go integer, addresses into an acm.Address, bytes given as hex into a []byte or byte
+// array, arrays into a slice or array and tuples into a struct
+func Decode(value string, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("cannot decode into %T, which is not a pointer", out)
+	}
+	return decode(value, v.Elem())
+}
+
+func decode(value string, v reflect.Value) error {
+	switch {
+	case v.Type() == addressType:
+		address, err := acm.AddressFromHexString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(address))
+		return nil
+	case v.Kind() == reflect.Ptr && v.Type().Elem() == bigIntType:
+		n, err := util.ParseInteger(value, nil)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(n))
+		return nil
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8:
+		b, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return fmt.Errorf("%s is not hex encoded: %v", value, err)
+		}
+		if v.Kind() == reflect.Slice {
+			v.SetBytes(b)
+		} else if len(b) > v.Len() {
+			return fmt.Errorf("%s does not fit in %s", value, v.Type())
+		} else {
+			reflect.Copy(v, reflect.ValueOf(b))
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Slice, reflect.Array, reflect.Struct:
+		elements, err := abi.SplitList(value)
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(v.Type(), len(elements), len(elements)))
+		case reflect.Array:
+			if len(elements) != v.Len() {
+				return fmt.Errorf("%s has %d elements, expected %d", value, len(elements), v.Len())
+			}
+		case reflect.Struct:
+			if len(elements) != v.NumField() {
+				return fmt.Errorf("%s has %d fields, expected %d", value, len(elements), v.NumField())
+			}
+		}
+		for i, element := range elements {
+			var err error
+			if v.Kind() == reflect.Struct {
+				err = decode(element, v.Field(i))
+			} else {
+				err = decode(element, v.Index(i))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decode(value, v.Elem())
+	default:
+		return fmt.Errorf("cannot decode into a value of type %s", v.Type())
+	}
+	return nil
+}
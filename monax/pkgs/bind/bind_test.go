@@ -0,0 +1,100 @@
+package bind
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+)
+
+type order struct {
+	Owner  acm.Address
+	Prices []*big.Int
+	Item   struct {
+		Id  [4]byte
+		Tag string
+	}
+}
+
+func TestFormatAndDecode(t *testing.T) {
+	owner, _ := acm.AddressFromHexString("1040E6521541DAB4E7EE57F21226DD17CE9F0FB7")
+	in := order{Owner: owner, Prices: []*big.Int{big.NewInt(5), big.NewInt(-7)}}
+	in.Item.Id = [4]byte{0xde, 0xad, 0xbe, 0xef}
+	in.Item.Tag = "marmot"
+
+	formatted, err := Format(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "(1040E6521541DAB4E7EE57F21226DD17CE9F0FB7,[5,-7],(0xdeadbeef,marmot))"; formatted != expected {
+		t.Errorf("expected %s, got %s", expected, formatted)
+	}
+	var out order
+	if err := Decode(formatted, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("expected %v to be decoded, got %v", in, out)
+	}
+
+	notes := []string{"hello, world", "[x]", "(y)", `say "hi"`, "", "plain"}
+	formatted, err = Format(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `["hello, world","[x]","(y)","say \"hi\"","",plain]`; formatted != expected {
+		t.Errorf("expected %s, got %s", expected, formatted)
+	}
+	var decoded []string
+	if err := Decode(formatted, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(notes, decoded) {
+		t.Errorf("expected %q to be decoded, got %q", notes, decoded)
+	}
+
+	for _, test := range []struct {
+		value    string
+		out      interface{}
+		expected interface{}
+	}{
+		{"true", new(bool), true},
+		{"255", new(uint8), uint8(255)},
+		{"-3", new(int64), int64(-3)},
+		{"1000000000000000000000", new(*big.Int), mustBig("1000000000000000000000")},
+		{"0x0102", new([]byte), []byte{1, 2}},
+		{"[1,2]", new([2]int), [2]int{1, 2}},
+	} {
+		if err := Decode(test.value, test.out); err != nil {
+			t.Errorf("unexpected error decoding %s: %v", test.value, err)
+			continue
+		}
+		if got := reflect.ValueOf(test.out).Elem().Interface(); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("expected %s to be decoded as %v, got %v", test.value, test.expected, got)
+		}
+	}
+
+	for _, test := range []struct {
+		value string
+		out   interface{}
+	}{
+		{"256", new(uint8)},
+		{"0x010203", new([2]byte)},
+		{"[1,2,3]", new([2]int)},
+		{"zz", new([]byte)},
+		{"1", 1},
+	} {
+		if err := Decode(test.value, test.out); err == nil {
+			t.Errorf("expected an error decoding %s into %T", test.value, test.out)
+		}
+	}
+	if _, err := Format([]*big.Int{nil}); err == nil {
+		t.Errorf("expected an error formatting a nil integer")
+	}
+}
+
+func mustBig(value string) *big.Int {
+	n, _ := new(big.Int).SetString(value, 10)
+	return n
+}
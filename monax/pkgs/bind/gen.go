@@ -0,0 +1,472 @@
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
+)
+
+// ContractSource is a contract to generate a binding for, one without bytecode being given no
+// deploy function
+type ContractSource struct {
+	Name     string
+	ABI      string
+	Bytecode string
+}
+
+// Generate writes the source of a go package with a binding for each of the contracts. Integers
+// are bound as *big.Int, addresses as acm.Address, bytes as []byte, arrays as slices and tuples as
+// structs. Functions are bound by their signature so overloads are bound as Name, Name1 and on,
+// skipping the names of other methods.
+func Generate(pkg string, contracts []ContractSource) ([]byte, error) {
+	if !isIdentifier(pkg) {
+		return nil, fmt.Errorf("%s is not a valid go package name", pkg)
+	}
+	data := struct {
+		Package   string
+		Contracts []*contractBinding
+	}{Package: pkg}
+	// the identifiers each contract declares in the package, by the contract declaring them
+	declared := make(map[string]string)
+	for _, contract := range contracts {
+		binding, err := bindContract(contract)
+		if err != nil {
+			return nil, fmt.Errorf("could not bind contract %s: %v", contract.Name, err)
+		}
+		if _, ok := declared[binding.Type]; ok {
+			return nil, fmt.Errorf("there are several contracts named %s", binding.Type)
+		}
+		for _, name := range binding.declared() {
+			if other, ok := declared[name]; ok {
+				return nil, fmt.Errorf("the bindings of %s and %s both declare %s", other, binding.Type, name)
+			}
+			declared[name] = binding.Type
+		}
+		data.Contracts = append(data.Contracts, binding)
+	}
+
+	var source bytes.Buffer
+	if err := bindingTemplate.Execute(&source, data); err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid go: %v", err)
+	}
+	return formatted, nil
+}
+
+type contractBinding struct {
+	Type        string
+	ABI         string
+	Bytecode    string
+	Constructor *methodBinding
+	Methods     []*methodBinding
+	Events      []*methodBinding
+	Structs     []*structBinding
+
+	structNames map[string]string
+	// the names of the structs generated for the contract, both for tuples and events
+	types map[string]bool
+}
+
+// declared lists the identifiers the binding of the contract declares in the package
+func (c *contractBinding) declared() []string {
+	names := []string{c.Type, c.Type + "ABI", "New" + c.Type}
+	if c.Bytecode != "" {
+		names = append(names, c.Type+"Bytecode", "Deploy"+c.Type)
+	}
+	for name := range c.types {
+		names = append(names, name)
+	}
+	return names
+}
+
+// methodBinding is a function, the constructor or an event, which is decoded into a struct
+type methodBinding struct {
+	Name      string
+	Signature string
+	ReadOnly  bool
+	Inputs    []*paramBinding
+	Outputs   []*paramBinding
+
+	Event  string
+	Struct string
+}
+
+type paramBinding struct {
+	Name string
+	Type string
+}
+
+// structBinding is the struct a tuple is bound as
+type structBinding struct {
+	Name   string
+	Fields []*paramBinding
+}
+
+func bindContract(contract ContractSource) (*contractBinding, error) {
+	functions, err := abi.ReadFunctions(contract.ABI)
+	if err != nil {
+		return nil, err
+	}
+	binding := &contractBinding{
+		Type:        exported(contract.Name),
+		ABI:         contract.ABI,
+		Bytecode:    strings.TrimPrefix(contract.Bytecode, "0x"),
+		structNames: make(map[string]string),
+		types:       make(map[string]bool),
+	}
+	if binding.Type == "" {
+		return nil, fmt.Errorf("the contract has no name")
+	}
+	// the methods are named after the functions, overloads being numbered, and the unpack methods
+	// after the events, none taking the name of the Address method or of another method
+	methods := map[string]bool{"Address": true}
+	for _, fn := range functions {
+		if fn.Type == "function" || fn.Type == "" {
+			methods[exported(fn.Name)] = true
+		}
+	}
+	named := make(map[string]bool)
+	for _, fn := range functions {
+		switch fn.Type {
+		case "function", "", "event", "constructor":
+		default:
+			continue
+		}
+		method := &methodBinding{ReadOnly: fn.ReadOnly()}
+		if method.Signature, err = fn.Signature(); err != nil {
+			return nil, err
+		}
+		if fn.Type == "event" {
+			method.Name = uniqueName(methods, "Unpack"+exported(fn.Name))
+			method.Event = fn.Name
+			method.Struct = uniqueName(binding.types, binding.Type+exported(fn.Name))
+		} else if fn.Type != "constructor" {
+			name := exported(fn.Name)
+			if name != "Address" && !named[name] {
+				// the first function of a name keeps it
+				method.Name = name
+				named[name] = true
+			} else {
+				method.Name = uniqueName(methods, name)
+			}
+		}
+		if method.Inputs, err = binding.params(fn.Name, fn.Inputs, fn.Type == "event"); err != nil {
+			return nil, err
+		}
+		if method.Outputs, err = binding.params(fn.Name, fn.Outputs, false); err != nil {
+			return nil, err
+		}
+		switch fn.Type {
+		case "event":
+			binding.Events = append(binding.Events, method)
+		case "constructor":
+			binding.Constructor = method
+		default:
+			binding.Methods = append(binding.Methods, method)
+		}
+	}
+	if binding.Constructor == nil {
+		binding.Constructor = &methodBinding{}
+	}
+	return binding, nil
+}
+
+// params names the arguments as go identifiers and gives their go types. The arguments of events
+// are exported as they are the fields of the struct of the event, of which indexed strings, bytes
+// and arrays are the hash
+func (c *contractBinding) params(fnName string, args []abi.Argument, event bool) ([]*paramBinding, error) {
+	params := make([]*paramBinding, len(args))
+	names := make(map[string]bool)
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = "arg" + strconv.Itoa(i)
+		}
+		param := &paramBinding{Name: uniqueName(names, unexported(name))}
+		if event {
+			param.Name = uniqueName(names, exported(name))
+		}
+		var err error
+		if event && arg.Indexed && (arg.Type == "string" || arg.Type == "bytes" ||
+			strings.HasSuffix(arg.Type, "]") || strings.HasPrefix(arg.Type, "tuple")) {
+			param.Type = "[]byte"
+		} else if param.Type, err = c.goType(fnName, arg); err != nil {
+			return nil, err
+		}
+		params[i] = param
+	}
+	return params, nil
+}
+
+var intType = regexp.MustCompile(`^u?int[0-9]*$`)
+var bytesType = regexp.MustCompile(`^bytes[0-9]*$`)
+
+func (c *contractBinding) goType(fnName string, arg abi.Argument) (string, error) {
+	typ := arg.Type
+	if i := strings.LastIndex(typ, "["); i >= 0 && strings.HasSuffix(typ, "]") {
+		element := arg
+		element.Type = typ[:i]
+		elementType, err := c.goType(fnName, element)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elementType, nil
+	}
+	switch {
+	case typ == "tuple":
+		return c.structType(fnName, arg)
+	case intType.MatchString(typ):
+		return "*big.Int", nil
+	case bytesType.MatchString(typ):
+		return "[]byte", nil
+	case typ == "address":
+		return "acm.Address", nil
+	case typ == "bool", typ == "string":
+		return typ, nil
+	}
+	return "", fmt.Errorf("cannot bind type %s", typ)
+}
+
+// structType returns the struct a tuple is bound as, named after the argument, one struct being
+// generated for each tuple type of the contract
+func (c *contractBinding) structType(fnName string, arg abi.Argument) (string, error) {
+	signature, err := abi.Function{Inputs: arg.Components}.Signature()
+	if err != nil {
+		return "", err
+	}
+	// the field names are part of the struct
+	for _, component := range arg.Components {
+		signature += " " + component.Name
+	}
+	if name, ok := c.structNames[signature]; ok {
+		return name, nil
+	}
+	base := c.Type + exported(arg.Name)
+	if arg.Name == "" {
+		base = c.Type + exported(fnName) + "Tuple"
+	}
+	name := uniqueName(c.types, base)
+	c.structNames[signature] = name
+	s := &structBinding{Name: name}
+	// declared before the fields are bound, so nested structs come after
+	c.Structs = append(c.Structs, s)
+	fields := make(map[string]bool)
+	for i, component := range arg.Components {
+		fieldName := component.Name
+		if fieldName == "" {
+			fieldName = "field" + strconv.Itoa(i)
+		}
+		typ, err := c.goType(fnName, component)
+		if err != nil {
+			return "", err
+		}
+		s.Fields = append(s.Fields, &paramBinding{Name: uniqueName(fields, exported(fieldName)), Type: typ})
+	}
+	return name, nil
+}
+
+// uniqueName returns the name, numbered when it is already used, and marks it as used
+func uniqueName(used map[string]bool, name string) string {
+	unique := name
+	for n := 1; used[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// identifiers the generated methods use, which parameters are renamed from
+var reserved = map[string]bool{
+	"acm": true, "big": true, "bind": true, "events": true, "rpc": true, "c": true, "session": true,
+	"contract": true, "result": true, "err": true, "event": true, "log": true,
+}
+
+var resultName = regexp.MustCompile(`^out[0-9]+$`)
+
+func exported(name string) string {
+	name = identifier(name)
+	if name == "" {
+		return ""
+	}
+	runes := []rune(strings.TrimLeft(name, "_"))
+	if len(runes) == 0 {
+		return "X" + name
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func unexported(name string) string {
+	name = identifier(name)
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	name = string(runes)
+	if reserved[name] || resultName.MatchString(name) || isKeyword(name) {
+		return name + "_"
+	}
+	return name
+}
+
+// identifier drops the characters of a name which cannot be part of a go identifier
+func identifier(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name)
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "_" + name
+	}
+	return name
+}
+
+func isIdentifier(name string) bool {
+	return name != "" && identifier(name) == name && !isKeyword(name)
+}
+
+func isKeyword(name string) bool {
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for",
+		"func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select",
+		"struct", "switch", "type", "var":
+		return true
+	}
+	return false
+}
+
+var bindingTemplate = template.Must(template.New("binding").Funcs(template.FuncMap{
+	"params": func(params []*paramBinding) string {
+		s := make([]string, len(params))
+		for i, p := range params {
+			s[i] = p.Name + " " + p.Type
+		}
+		return strings.Join(s, ", ")
+	},
+	"names": func(params []*paramBinding) string {
+		s := make([]string, len(params))
+		for i, p := range params {
+			s[i] = p.Name
+		}
+		return strings.Join(s, ", ")
+	},
+	"results": func(params []*paramBinding) string {
+		s := make([]string, len(params))
+		for i := range params {
+			s[i] = "&out" + strconv.Itoa(i)
+		}
+		return strings.Join(s, ", ")
+	},
+	"returns": func(params []*paramBinding) string {
+		s := make([]string, len(params)+1)
+		for i, p := range params {
+			s[i] = p.Type
+		}
+		s[len(params)] = "error"
+		if len(params) == 0 {
+			return "error"
+		}
+		return "(" + strings.Join(s, ", ") + ")"
+	},
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by bos abi gen-go. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"math/big"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/execution/evm/events"
+	"github.com/monax/bosmarmot/monax/pkgs/bind"
+)
+
+// Reference the imports in case the contracts do not use them
+var (
+	_ = big.NewInt
+	_ = acm.ZeroAddress
+	_ = rpc.TxResult{}
+	_ = events.EventDataLog{}
+)
+{{range $c := .Contracts}}
+// {{$c.Type}}ABI is the abi of {{$c.Type}}
+const {{$c.Type}}ABI = {{quote $c.ABI}}
+{{if $c.Bytecode}}
+// {{$c.Type}}Bytecode is the bytecode deploying {{$c.Type}}
+const {{$c.Type}}Bytecode = {{quote $c.Bytecode}}
+{{end}}
+// {{$c.Type}} is a binding to a deployed {{$c.Type}} contract
+type {{$c.Type}} struct {
+	contract *bind.Contract
+}
+
+// New{{$c.Type}} binds the {{$c.Type}} contract deployed at the address
+func New{{$c.Type}}(address acm.Address, session *bind.Session) *{{$c.Type}} {
+	return &{{$c.Type}}{contract: bind.NewContract({{$c.Type}}ABI, address, session)}
+}
+{{if $c.Bytecode}}
+// Deploy{{$c.Type}} deploys a {{$c.Type}} contract and binds it
+func Deploy{{$c.Type}}(session *bind.Session{{range $c.Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (*{{$c.Type}}, *rpc.TxResult, error) {
+	contract, result, err := bind.Deploy(session, {{$c.Type}}ABI, {{$c.Type}}Bytecode{{range $c.Constructor.Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return nil, result, err
+	}
+	return &{{$c.Type}}{contract: contract}, result, nil
+}
+{{end}}
+// Address is the address of the contract
+func (c *{{$c.Type}}) Address() acm.Address {
+	return c.contract.Address
+}
+{{range $c.Structs}}
+// {{.Name}} is a tuple of the {{$c.Type}} abi
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+{{- range $m := $c.Methods}}
+{{- if $m.ReadOnly}}
+// {{$m.Name}} queries {{$m.Signature}}
+func (c *{{$c.Type}}) {{$m.Name}}({{params $m.Inputs}}) {{returns $m.Outputs}} {
+{{- range $i, $o := $m.Outputs}}
+	var out{{$i}} {{$o.Type}}
+{{- end}}
+	err := c.contract.Call({{quote $m.Signature}}, []interface{}{ {{- names $m.Inputs -}} }{{if $m.Outputs}}, {{results $m.Outputs}}{{end}})
+	return {{range $i, $o := $m.Outputs}}out{{$i}}, {{end}}err
+}
+{{else}}
+// {{$m.Name}} sends a transaction calling {{$m.Signature}}
+func (c *{{$c.Type}}) {{$m.Name}}({{params $m.Inputs}}) (*rpc.TxResult, error) {
+	return c.contract.Transact({{quote $m.Signature}}{{range $m.Inputs}}, {{.Name}}{{end}})
+}
+{{end}}
+{{- end}}
+{{- range $e := $c.Events}}
+// {{$e.Struct}} is a {{$e.Event}} event logged by {{$c.Type}}
+type {{$e.Struct}} struct {
+{{- range $e.Inputs}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+
+// {{$e.Name}} decodes a log of {{$e.Signature}}
+func (c *{{$c.Type}}) {{$e.Name}}(log *events.EventDataLog) (*{{$e.Struct}}, error) {
+	event := new({{$e.Struct}})
+	err := c.contract.UnpackLog({{quote $e.Event}}, log{{range $e.Inputs}}, &event.{{.Name}}{{end}})
+	return event, err
+}
+{{end}}
+{{- end}}`))
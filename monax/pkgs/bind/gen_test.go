@@ -0,0 +1,131 @@
+package bind
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const tokenABI = `[
+{"type":"constructor","inputs":[{"name":"supply","type":"uint256"},{"name":"type","type":"string"}]},
+{"type":"function","name":"transfer","constant":false,"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],
+	"outputs":[{"name":"","type":"bool"}]},
+{"type":"function","name":"transfer","constant":false,"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"},
+	{"name":"memo","type":"bytes"}],"outputs":[]},
+{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],
+	"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"orders","constant":true,"inputs":[],"outputs":[
+	{"name":"open","type":"tuple[]","components":[{"name":"owner","type":"address"},{"name":"prices","type":"uint8[2]"},
+		{"name":"item","type":"tuple","components":[{"name":"id","type":"bytes32"}]}]},
+	{"name":"count","type":"int64"}]},
+{"type":"function","name":"place","inputs":[{"name":"open","type":"tuple","components":[{"name":"owner","type":"address"},
+	{"name":"prices","type":"uint8[2]"},{"name":"item","type":"tuple","components":[{"name":"id","type":"bytes32"}]}]}],"outputs":[]},
+{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},
+	{"name":"memo","type":"string","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+{"type":"fallback","payable":true}
+]`
+
+func TestGenerate(t *testing.T) {
+	source, err := Generate("tokens", []ContractSource{{Name: "Token", ABI: tokenABI, Bytecode: "0x6060"},
+		{Name: "lib:Math", ABI: `[]`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"package tokens",
+		`const TokenBytecode = "6060"`,
+		"func DeployToken(session *bind.Session, supply *big.Int, type_ string) (*Token, *rpc.TxResult, error)",
+		"func (c *Token) Transfer(to acm.Address, amount *big.Int) (*rpc.TxResult, error)",
+		`return c.contract.Transact("transfer(address,uint256)", to, amount)`,
+		"func (c *Token) Transfer1(to acm.Address, amount *big.Int, memo []byte) (*rpc.TxResult, error)",
+		"func (c *Token) BalanceOf(owner acm.Address) (*big.Int, error)",
+		`err := c.contract.Call("balanceOf(address)", []interface{}{owner}, &out0)`,
+		"func (c *Token) Orders() ([]TokenOpen, *big.Int, error)",
+		"func (c *Token) Place(open TokenOpen) (*rpc.TxResult, error)",
+		"Prices []*big.Int",
+		"Item   TokenItem",
+		"Memo  []byte",
+		"func (c *Token) UnpackTransfer(log *events.EventDataLog) (*TokenTransfer, error)",
+		`err := c.contract.UnpackLog("Transfer", log, &event.From, &event.Memo, &event.Value)`,
+		"func NewLibMath(address acm.Address, session *bind.Session) *LibMath",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("expected the binding to contain %s", expected)
+		}
+	}
+	if strings.Contains(string(source), "DeployLibMath") {
+		t.Errorf("expected no deploy function for a contract without bytecode")
+	}
+	if t.Failed() {
+		t.Log(string(source))
+	}
+
+	for _, contracts := range [][]ContractSource{
+		{{Name: "Token", ABI: tokenABI}, {Name: "token", ABI: tokenABI}},
+		{{Name: "Token", ABI: `[{"type":"function","name":"f","inputs":[{"name":"","type":"fixed128x18"}]}]`}},
+		{{Name: "", ABI: tokenABI}},
+	} {
+		if _, err := Generate("tokens", contracts); err == nil {
+			t.Errorf("expected an error binding %v", contracts)
+		}
+	}
+	if _, err := Generate("func", nil); err == nil {
+		t.Errorf("expected an error for an invalid package name")
+	}
+}
+
+// clashingABI has functions and events whose methods would share a name
+const clashingABI = `[
+{"type":"function","name":"address","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+{"type":"function","name":"foo","inputs":[],"outputs":[]},
+{"type":"function","name":"foo","inputs":[{"name":"a","type":"uint256"},{"name":"A","type":"uint256"}],"outputs":[]},
+{"type":"function","name":"foo1","inputs":[],"outputs":[]},
+{"type":"function","name":"unpackStored","inputs":[],"outputs":[]},
+{"type":"function","name":"keep","inputs":[{"name":"stored","type":"tuple","components":[{"name":"x","type":"bool"},
+	{"name":"X","type":"bool"}]}],"outputs":[]},
+{"type":"event","name":"Stored","inputs":[{"name":"value","type":"uint256","indexed":false}]},
+{"type":"event","name":"Stored","inputs":[{"name":"value","type":"bool","indexed":false}]}
+]`
+
+func TestGenerateClashingNames(t *testing.T) {
+	source, err := Generate("clashes", []ContractSource{{Name: "Box", ABI: clashingABI}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"func (c *Box) Address() acm.Address",
+		"func (c *Box) Address1() (acm.Address, error)",
+		"func (c *Box) Foo() (*rpc.TxResult, error)",
+		"func (c *Box) Foo2(a *big.Int, a1 *big.Int) (*rpc.TxResult, error)",
+		"func (c *Box) Foo1() (*rpc.TxResult, error)",
+		"func (c *Box) UnpackStored() (*rpc.TxResult, error)",
+		"func (c *Box) UnpackStored1(log *events.EventDataLog) (*BoxStored1, error)",
+		"func (c *Box) UnpackStored2(log *events.EventDataLog) (*BoxStored2, error)",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("expected the binding to contain %s", expected)
+		}
+	}
+
+	// the generated package must compile, so it is type checked from the directory of this package
+	// for its imports to be found
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(dir, "clashes.go"), source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("clashes", fset, []*ast.File{file}, nil); err != nil {
+		t.Errorf("generated binding does not compile: %v\n%s", err, source)
+	}
+}
@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	compilers "github.com/monax/bosmarmot/compilers/perform"
+)
+
+// the artifact formats deploy reads contracts from, instead of compiling them
+
+// combinedJSONContract is a contract of solc --combined-json output, keyed by file:contract
+type combinedJSONContract struct {
+	ABI        json.RawMessage `json:"abi"`
+	Bin        string          `json:"bin"`
+	BinRuntime string          `json:"bin-runtime"`
+}
+
+// standardJSONContract is a contract of solc --standard-json output, keyed by file then contract
+type standardJSONContract struct {
+	ABI json.RawMessage `json:"abi"`
+	EVM struct {
+		Bytecode struct {
+			Object string `json:"object"`
+		} `json:"bytecode"`
+		DeployedBytecode struct {
+			Object string `json:"object"`
+		} `json:"deployedBytecode"`
+	} `json:"evm"`
+}
+
+// truffleArtifact is the artifact truffle writes for each contract
+type truffleArtifact struct {
+	ContractName     string          `json:"contractName"`
+	ABI              json.RawMessage `json:"abi"`
+	Bytecode         string          `json:"bytecode"`
+	DeployedBytecode string          `json:"deployedBytecode"`
+}
+
+// readArtifacts reads the contracts of a solc combined-json or standard-json output file, or of a
+// truffle artifact, as the compilers would have returned them. The contracts of solc output are
+// named file:contract after the source they were compiled from, which is the name their abi is
+// saved under
+func readArtifacts(file string) ([]compilers.ResponseItem, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := parseArtifacts(b)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the contracts of artifact %s: %v", file, err)
+	}
+	return objects, nil
+}
+
+func parseArtifacts(artifact []byte) ([]compilers.ResponseItem, error) {
+	var top struct {
+		ContractName string                     `json:"contractName"`
+		Contracts    map[string]json.RawMessage `json:"contracts"`
+	}
+	if err := json.Unmarshal(artifact, &top); err != nil {
+		return nil, err
+	}
+
+	var objects []compilers.ResponseItem
+	switch {
+	case top.ContractName != "":
+		var contract truffleArtifact
+		if err := json.Unmarshal(artifact, &contract); err != nil {
+			return nil, err
+		}
+		object, err := responseItem(contract.ContractName, contract.ABI, contract.Bytecode, contract.DeployedBytecode)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	case len(top.Contracts) > 0:
+		for _, key := range sortedRawKeys(top.Contracts) {
+			// combined-json keys contracts by file:contract, standard-json by file and then contract
+			if strings.Contains(key, ":") {
+				var contract combinedJSONContract
+				if err := json.Unmarshal(top.Contracts[key], &contract); err != nil {
+					return nil, err
+				}
+				object, err := responseItem(key, contract.ABI, contract.Bin, contract.BinRuntime)
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, object)
+				continue
+			}
+			var file map[string]standardJSONContract
+			if err := json.Unmarshal(top.Contracts[key], &file); err != nil {
+				return nil, err
+			}
+			var names []string
+			for name := range file {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				contract := file[name]
+				object, err := responseItem(key+":"+name, contract.ABI, contract.EVM.Bytecode.Object,
+					contract.EVM.DeployedBytecode.Object)
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, object)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("not a solc combined-json or standard-json output or a truffle artifact")
+	}
+	return objects, nil
+}
+
+// responseItem takes the abi as solc gives it, either json encoded as a string or inline, and the
+// bytecode with or without a 0x prefix
+func responseItem(name string, abiData json.RawMessage, bytecode, runtime string) (compilers.ResponseItem, error) {
+	abiString := string(abiData)
+	if bytes.HasPrefix(bytes.TrimSpace(abiData), []byte(`"`)) {
+		if err := json.Unmarshal(abiData, &abiString); err != nil {
+			return compilers.ResponseItem{}, err
+		}
+	} else if len(abiData) > 0 {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, abiData); err != nil {
+			return compilers.ResponseItem{}, err
+		}
+		abiString = compact.String()
+	}
+	return compilers.ResponseItem{
+		Objectname:      name,
+		Bytecode:        strings.TrimPrefix(strings.TrimSpace(bytecode), "0x"),
+		ABI:             strings.TrimSpace(abiString),
+		RuntimeBytecode: strings.TrimPrefix(strings.TrimSpace(runtime), "0x"),
+	}, nil
+}
+
+func sortedRawKeys(index map[string]json.RawMessage) []string {
+	var keys []string
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
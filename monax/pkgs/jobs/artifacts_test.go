@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"reflect"
+	"testing"
+
+	compilers "github.com/monax/bosmarmot/compilers/perform"
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+const artifactABI = `[{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`
+
+func TestParseArtifacts(t *testing.T) {
+	storage := compilers.ResponseItem{
+		Objectname:      "Storage",
+		Bytecode:        "6060604052",
+		ABI:             artifactABI,
+		RuntimeBytecode: "60606040",
+	}
+	lib := compilers.ResponseItem{Objectname: "lib.sol:Lib", Bytecode: "6061", ABI: "[]", RuntimeBytecode: "6062"}
+	truffle := storage
+	storage.Objectname = "storage.sol:Storage"
+
+	for _, test := range []struct {
+		name     string
+		artifact string
+		expected []compilers.ResponseItem
+	}{
+		{"combined-json with the abi as a string",
+			`{"contracts":{"storage.sol:Storage":{"abi":"` + `[{\"constant\":true,\"inputs\":[],\"name\":\"get\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"type\":\"function\"}]` +
+				`","bin":"6060604052","bin-runtime":"60606040"},"lib.sol:Lib":{"abi":"[]","bin":"6061","bin-runtime":"6062"}},"version":"0.4.24"}`,
+			[]compilers.ResponseItem{lib, storage}},
+		{"combined-json with the abi inline",
+			`{"contracts":{"storage.sol:Storage":{"abi": ` + artifactABI + `,"bin":"6060604052","bin-runtime":"60606040"}}}`,
+			[]compilers.ResponseItem{storage}},
+		{"standard-json",
+			`{"contracts":{"storage.sol":{"Storage":{"abi":` + artifactABI + `,"evm":{"bytecode":{"object":"6060604052"},` +
+				`"deployedBytecode":{"object":"60606040"}}}},"lib.sol":{"Lib":{"abi":[],"evm":{"bytecode":{"object":"6061"},` +
+				`"deployedBytecode":{"object":"6062"}}}}},"sources":{}}`,
+			[]compilers.ResponseItem{lib, storage}},
+		{"truffle",
+			`{"contractName":"Storage","abi":` + artifactABI + `,"bytecode":"0x6060604052","deployedBytecode":"0x60606040"}`,
+			[]compilers.ResponseItem{truffle}},
+	} {
+		objects, err := parseArtifacts([]byte(test.artifact))
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %v", test.name, err)
+		} else if !reflect.DeepEqual(objects, test.expected) {
+			t.Errorf("expected %s to be parsed to %v, got %v", test.name, test.expected, objects)
+		}
+	}
+
+	for _, artifact := range []string{`{"abi":[]}`, `[]`, `{"contracts":{"storage.sol":[]}}`} {
+		if _, err := parseArtifacts([]byte(artifact)); err == nil {
+			t.Errorf("expected an error parsing %s", artifact)
+		}
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	do := definitions.NowDo()
+	do.Path = "/pkg"
+	deploy := &definitions.Deploy{Contract: "/pkg/build/combined.json"}
+	for object, expected := range map[string]string{
+		"contracts/storage.sol:Storage": "contracts/storage.sol:Storage",
+		"Storage":                       "build/combined.json:Storage",
+	} {
+		if name := qualifiedName(do, deploy, object); name != expected {
+			t.Errorf("expected the abi of %s to be saved under %s, got %s", object, expected, name)
+		}
+		if !matchInstanceName(object, "storage") {
+			t.Errorf("expected %s to match the instance storage", object)
+		}
+	}
+}
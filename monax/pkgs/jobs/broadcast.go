@@ -0,0 +1,342 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	exe_events "github.com/hyperledger/burrow/execution/events"
+	evm_events "github.com/hyperledger/burrow/execution/evm/events"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/logging/loggers"
+	burrow_rpc "github.com/hyperledger/burrow/rpc"
+	tm_client "github.com/hyperledger/burrow/rpc/tm/client"
+	"github.com/hyperledger/burrow/txs"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
+	"github.com/monax/bosmarmot/monax/util"
+	rpcclient "github.com/tendermint/tendermint/rpc/lib/client"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+// txResult is the outcome of a committed call (or deploy) transaction along with the logs emitted
+// by the called (or created) contract, and by the contracts it calls in turn whose abis are stored,
+// and the gas the transaction used. Like the block hash, the
+// block height is that of the latest block when the transaction was committed.
+type txResult struct {
+	*rpc.TxResult
+	// the contract called, or created
+	Contract    acm.Address
+	Logs        []*evm_events.EventDataLog
+	GasUsed     uint64
+	BlockHeight int64
+}
+
+// receipts are the results of the transactions sent while running a package by their hash in hex,
+// which the node does not keep, so query-tx jobs can look them up afterwards. RunJobs starts
+// each run with none
+var receipts = make(map[string]*txResult)
+
+// signAndBroadcast signs a call (or deploy) transaction, broadcasts it and waits for it to be
+// committed. Unlike rpc.SignAndBroadcast an exception thrown while executing the transaction is
+// not returned as an error but recorded in the result (along with any data the EVM returned)
+// so that jobs can decide whether the exception was expected.
+func signAndBroadcast(do *definitions.Do, tx *txs.CallTx) (*txResult, error) {
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	keyClient := keys.NewKeyClient(do.Signer, loggers.NewNoopInfoTraceLogger())
+	_, chainID, _, err := nodeClient.ChainId()
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign only, the transaction is signed in place
+	if _, err := rpc.SignAndBroadcast(chainID, nodeClient, keyClient, tx, true, false, false); err != nil {
+		return nil, err
+	}
+
+	wsClient := rpcclient.NewWSClient(do.ChainURL, "/websocket")
+	if err := wsClient.Start(); err != nil {
+		return nil, err
+	}
+	defer wsClient.Stop()
+
+	inputEventID := exe_events.EventStringAccountInput(tx.Input.Address)
+	if err := tm_client.Subscribe(wsClient, inputEventID); err != nil {
+		return nil, fmt.Errorf("Error subscribing to AccInput event (%s): %v", inputEventID, err)
+	}
+	if err := tm_client.Subscribe(wsClient, tm_types.EventNewBlock); err != nil {
+		return nil, fmt.Errorf("Error subscribing to NewBlock event: %v", err)
+	}
+
+	var contractAddress acm.Address
+	if tx.Address == nil {
+		contractAddress = acm.NewContractAddress(tx.Input.Address, tx.Input.Sequence)
+	} else {
+		contractAddress = *tx.Address
+	}
+	// the node only sends the events of the accounts subscribed to. Besides the contract called, or
+	// created, the contracts it calls may emit events of their own, which can only be decoded with
+	// their abi, so the contracts whose abis are stored for the chain are subscribed to as well. The
+	// call event of each contract holds the transaction, and that of the contract called holds the
+	// gas left over once it has executed
+	contracts := knownContracts(do, chainID, contractAddress)
+	logEventIDs := make(map[string]bool)
+	callEventIDs := make(map[string]bool)
+	for _, address := range contracts {
+		logEventID := evm_events.EventStringLogEvent(address)
+		if err := tm_client.Subscribe(wsClient, logEventID); err != nil {
+			return nil, fmt.Errorf("Error subscribing to Log event (%s): %v", logEventID, err)
+		}
+		logEventIDs[tm_client.EventResponseID(logEventID)] = true
+		callEventID := evm_events.EventStringAccountCall(address)
+		if err := tm_client.Subscribe(wsClient, callEventID); err != nil {
+			return nil, fmt.Errorf("Error subscribing to Call event (%s): %v", callEventID, err)
+		}
+		callEventIDs[tm_client.EventResponseID(callEventID)] = true
+	}
+
+	receipt, err := nodeClient.Broadcast(tx)
+	if err != nil {
+		return nil, err
+	}
+	result := &txResult{
+		TxResult: &rpc.TxResult{
+			Hash: receipt.TxHash,
+		},
+		Contract: contractAddress,
+	}
+	if tx.Address == nil {
+		result.Address = &contractAddress
+	}
+
+	// logs are fired while the transaction executes, before the event for the transaction itself,
+	// so anything collected belongs to another transaction once we see its event or a new block.
+	// Logs do not name their transaction, but the call event of a contract follows the logs it
+	// emitted during the call, so those are kept when the call was made by the transaction
+	var logs []*pendingLog
+
+	var latestBlockHash []byte
+	var latestBlockHeight int64
+	timeout := time.After(client.MaxCommitWaitTimeSeconds * time.Second)
+	for {
+		select {
+		case <-timeout:
+			return nil, fmt.Errorf("timed out waiting for transaction %X to be committed", receipt.TxHash)
+
+		case response, ok := <-wsClient.ResponsesCh:
+			if !ok {
+				return nil, fmt.Errorf("websocket closed while waiting for transaction %X to be committed", receipt.TxHash)
+			}
+			if response.Error != nil {
+				log.WithField("=>", response.Error).Debug("Error received on websocket channel")
+				continue
+			}
+
+			switch response.ID {
+			case tm_client.EventResponseID(tm_types.EventNewBlock):
+				resultEvent := new(burrow_rpc.ResultEvent)
+				if err := json.Unmarshal(response.Result, resultEvent); err != nil {
+					log.WithField("=>", err).Debug("Unable to unmarshal new block event")
+					continue
+				}
+				if blockData := resultEvent.EventDataNewBlock(); blockData != nil {
+					latestBlockHash = blockData.Block.Hash()
+					latestBlockHeight = blockData.Block.Height
+				}
+				logs = nil
+
+			case tm_client.EventResponseID(inputEventID):
+				resultEvent := new(burrow_rpc.ResultEvent)
+				if err := json.Unmarshal(response.Result, resultEvent); err != nil {
+					return nil, fmt.Errorf("unable to unmarshal transaction event: %v", err)
+				}
+				eventDataTx := resultEvent.EventDataTx
+				if eventDataTx == nil {
+					return nil, fmt.Errorf("response error: expected result.Data to be *types.EventDataTx")
+				}
+				if !bytes.Equal(txs.TxHash(chainID, eventDataTx.Tx), receipt.TxHash) {
+					logs = nil
+					continue
+				}
+				result.BlockHash = latestBlockHash
+				result.BlockHeight = latestBlockHeight
+				result.Return = eventDataTx.Return
+				result.Exception = eventDataTx.Exception
+				if result.Exception == "" {
+					// a reverted transaction leaves no logs behind
+					result.Logs = ownLogs(logs)
+				}
+				receipts[fmt.Sprintf("%X", result.Hash)] = result
+				return result, nil
+
+			default:
+				resultEvent := new(burrow_rpc.ResultEvent)
+				switch {
+				case callEventIDs[response.ID]:
+					if err := json.Unmarshal(response.Result, resultEvent); err != nil {
+						log.WithField("=>", err).Debug("Unable to unmarshal call event")
+						continue
+					}
+					callData := resultEvent.EventDataCall
+					if callData == nil || callData.CallData == nil {
+						continue
+					}
+					ours := bytes.Equal(callData.TxID, receipt.TxHash)
+					logs = callReturned(logs, callData.CallData.Callee, ours)
+					// only the outermost call counts for the gas, contracts may call themselves
+					if ours && callData.CallData.Callee == contractAddress &&
+						callData.CallData.Caller == tx.Input.Address && callData.CallData.Gas <= tx.GasLimit {
+						result.GasUsed = tx.GasLimit - callData.CallData.Gas
+					}
+
+				case logEventIDs[response.ID]:
+					if err := json.Unmarshal(response.Result, resultEvent); err != nil {
+						log.WithField("=>", err).Debug("Unable to unmarshal log event")
+						continue
+					}
+					if resultEvent.EventDataLog != nil {
+						logs = append(logs, &pendingLog{EventDataLog: resultEvent.EventDataLog})
+					}
+				}
+			}
+		}
+	}
+}
+
+// pendingLog is a log received while waiting for the transaction, which is known to be emitted by
+// the transaction once the call to the contract emitting it returns
+type pendingLog struct {
+	*evm_events.EventDataLog
+	returned bool
+	ours     bool
+}
+
+// callReturned settles the logs emitted by a contract whose call has just returned, dropping them
+// when the call was made by another transaction
+func callReturned(logs []*pendingLog, callee acm.Address, ours bool) []*pendingLog {
+	var kept []*pendingLog
+	for _, l := range logs {
+		if !l.returned && l.Address == callee {
+			if !ours {
+				continue
+			}
+			l.returned, l.ours = true, true
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
+
+// ownLogs are the logs emitted by the transaction, in the order they were emitted
+func ownLogs(logs []*pendingLog) []*evm_events.EventDataLog {
+	var own []*evm_events.EventDataLog
+	for _, l := range logs {
+		if l.ours {
+			own = append(own, l.EventDataLog)
+		}
+	}
+	return own
+}
+
+// knownContracts lists the contract along with the contracts deployed to the chain whose abis are
+// stored, which is read locally rather than from the chain
+func knownContracts(do *definitions.Do, chainID string, contract acm.Address) []acm.Address {
+	contracts := []acm.Address{contract}
+	addresses, err := util.NewABIStore(do.ABIPath, chainID).Addresses(chainID)
+	if err != nil {
+		log.WithField("=>", err).Debug("Could not read the addresses of the stored abis")
+		return contracts
+	}
+	for key := range addresses {
+		address, err := acm.AddressFromHexString(key)
+		if err == nil && address != contract {
+			contracts = append(contracts, address)
+		}
+	}
+	return contracts
+}
+
+// checkExpect makes sure the expect field of a call or deploy job holds something we understand
+// before any transaction is sent
+func checkExpect(expect string) error {
+	switch expect {
+	case "", "revert":
+		return nil
+	default:
+		return fmt.Errorf("Unknown expectation \"%s\", the only supported expectation is \"revert\"", expect)
+	}
+}
+
+// revertError is returned when a transaction throws unexpectedly. The job manager names the failing
+// job in the message and keeps the data returned by the contract in the job's output.
+type revertError struct {
+	Exception string
+	Reason    string
+	Return    []byte
+}
+
+func (e *revertError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("transaction reverted: %s", e.Reason)
+	}
+	return fmt.Sprintf("transaction confirmed but execution gave exception: %s", e.Exception)
+}
+
+// revertReason decodes the reason a transaction reverted from the data it returned, see
+// abi.UnpackRevert. It is empty when the contract gave no reason we can decode.
+func revertReason(abiData string, res *txResult) string {
+	if len(res.Return) == 0 {
+		return ""
+	}
+	reason, err := abi.UnpackRevert(abiData, res.Return)
+	if err != nil {
+		log.WithField("=>", err).Debug("Could not decode revert reason")
+		return ""
+	}
+	return reason
+}
+
+// expectationMet compares the outcome of a committed transaction with what the job expected. It
+// returns the revert reason (or the exception when there is no reason) if the transaction reverted
+// as expected, or an error when the transaction threw unexpectedly or succeeded when it should have
+// reverted. abiData is used to decode custom errors and may be empty.
+func expectationMet(expect, expectReason, abiData string, res *txResult) (string, error) {
+	var reason string
+	if res.Exception != "" {
+		reason = revertReason(abiData, res)
+	}
+
+	switch {
+	case expect == "revert" && res.Exception != "":
+		message := reason
+		if message == "" {
+			message = res.Exception
+		}
+		if !strings.Contains(message, expectReason) {
+			return "", fmt.Errorf("transaction %X reverted as expected but with \"%s\" rather than a reason containing \"%s\"",
+				res.Hash, message, expectReason)
+		}
+		log.WithField("=>", message).Warn("Transaction Reverted As Expected")
+		return message, nil
+	case expect == "revert":
+		return "", fmt.Errorf("transaction %X was expected to revert but succeeded", res.Hash)
+	case res.Exception != "":
+		log.WithFields(log.Fields{
+			"exception": res.Exception,
+			"return":    fmt.Sprintf("%X", res.Return),
+		}).Error("Transaction Reverted")
+		return "", &revertError{
+			Exception: res.Exception,
+			Reason:    reason,
+			Return:    res.Return,
+		}
+	default:
+		return "", nil
+	}
+}
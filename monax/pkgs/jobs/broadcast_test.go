@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client/rpc"
+	evm_events "github.com/hyperledger/burrow/execution/evm/events"
+)
+
+// Error(string) with the message "too many marmots"
+var revertData = concat([]byte{0x08, 0xc3, 0x79, 0xa0}, word(32), word(16), []byte("too many marmots"), make([]byte, 16))
+
+func word(b byte) []byte {
+	w := make([]byte, 32)
+	w[31] = b
+	return w
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func TestExpectationMet(t *testing.T) {
+	succeeded := &txResult{TxResult: &rpc.TxResult{}}
+	threw := &txResult{TxResult: &rpc.TxResult{Exception: "Invalid opcode"}}
+	reverted := &txResult{TxResult: &rpc.TxResult{Exception: "Execution reverted", Return: revertData}}
+
+	for _, test := range []struct {
+		expect, expectReason string
+		res                  *txResult
+		result               string
+		err                  string
+	}{
+		{"", "", succeeded, "", ""},
+		{"revert", "", succeeded, "", "expected to revert but succeeded"},
+		{"revert", "", threw, "Invalid opcode", ""},
+		{"revert", "opcode", threw, "Invalid opcode", ""},
+		{"revert", "marmots", threw, "", "rather than a reason containing"},
+		{"revert", "marmots", reverted, "too many marmots", ""},
+		{"", "", threw, "", "execution gave exception: Invalid opcode"},
+		{"", "", reverted, "", "transaction reverted: too many marmots"},
+	} {
+		result, err := expectationMet(test.expect, test.expectReason, "", test.res)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if result != test.result {
+			t.Errorf("expected %q, got %q", test.result, result)
+		}
+	}
+
+	_, err := expectationMet("", "", "", reverted)
+	if revert, ok := err.(*revertError); !ok || len(revert.Return) != len(revertData) {
+		t.Errorf("expected the revert error to keep the returned data, got %v", err)
+	}
+}
+
+func TestCallReturned(t *testing.T) {
+	outer, inner := acm.Address{1}, acm.Address{2}
+	logOf := func(address acm.Address, data string) *pendingLog {
+		return &pendingLog{EventDataLog: &evm_events.EventDataLog{Address: address, Data: []byte(data)}}
+	}
+	// another transaction's log of the inner contract, then ours: the outer contract emits, calls
+	// the inner one which emits, and emits again
+	logs := []*pendingLog{logOf(inner, "other"), logOf(outer, "a1")}
+	logs = callReturned(logs, inner, false)
+	logs = append(logs, logOf(inner, "b1"))
+	logs = callReturned(logs, inner, true)
+	logs = append(logs, logOf(outer, "a2"))
+	logs = callReturned(logs, outer, true)
+
+	var emitted []string
+	for _, l := range ownLogs(logs) {
+		emitted = append(emitted, string(l.Data))
+	}
+	if strings.Join(emitted, ",") != "a1,b1,a2" {
+		t.Errorf("expected the logs of the transaction in the order emitted, got %v", emitted)
+	}
+}
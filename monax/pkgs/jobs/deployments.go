@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+)
+
+// Deployments is the registry of the contracts deployed to a chain, kept in
+// <deployments-path>/<chain id>.json so that packages can be rerun without redeploying
+type Deployments struct {
+	ChainID     string        `json:"chainId"`
+	Deployments []*Deployment `json:"deployments"`
+}
+
+// Deployment records a contract deployed by a deploy job. Args holds the hex of the packed
+// constructor arguments and the hashes are keccak256 of the linked bytecode (without the
+// arguments) and of the abi.
+type Deployment struct {
+	Job          string `json:"job"`
+	Contract     string `json:"contract"`
+	Address      string `json:"address"`
+	BytecodeHash string `json:"bytecodeHash"`
+	ABIHash      string `json:"abiHash"`
+	Args         string `json:"args"`
+	TxHash       string `json:"txHash"`
+	BlockHeight  int64  `json:"blockHeight"`
+}
+
+func hashHex(data string) string {
+	return strings.ToUpper(common.Bytes2Hex(crypto.Keccak256([]byte(data))))
+}
+
+// deploymentsFile is the registry of the chain the package is run against, whose id is read when
+// the package starts running
+func deploymentsFile(do *definitions.Do) (string, error) {
+	if do.ChainID == "" {
+		return "", fmt.Errorf("the id of the chain is not known, so its deployments cannot be looked up")
+	}
+	return filepath.Join(do.DeploymentsPath, do.ChainID+".json"), nil
+}
+
+// loadDeployments reads the registry of the chain the package is run against
+func loadDeployments(do *definitions.Do) (*Deployments, string, error) {
+	file, err := deploymentsFile(do)
+	if err != nil {
+		return nil, "", err
+	}
+	deployments, err := readDeployments(file, do.ChainID)
+	return deployments, file, err
+}
+
+// readDeployments reads a registry, which is empty when nothing has been deployed to the chain yet
+func readDeployments(file, chainID string) (*Deployments, error) {
+	deployments := &Deployments{ChainID: chainID}
+	contents, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return deployments, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contents, deployments); err != nil {
+		return nil, fmt.Errorf("could not read deployments file %s: %v", file, err)
+	}
+	return deployments, nil
+}
+
+func (deployments *Deployments) write(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0775); err != nil {
+		return err
+	}
+	contents, err := json.MarshalIndent(deployments, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, contents, 0664)
+}
+
+// find returns the recorded deployment of a contract by a job
+func (deployments *Deployments) find(job, contract string) *Deployment {
+	for _, deployment := range deployments.Deployments {
+		if deployment.Job == job && deployment.Contract == contract {
+			return deployment
+		}
+	}
+	return nil
+}
+
+// record adds the deployment of a contract by a job, replacing any earlier deployment by the job
+func (deployments *Deployments) record(deployment *Deployment) {
+	if recorded := deployments.find(deployment.Job, deployment.Contract); recorded != nil {
+		*recorded = *deployment
+	} else {
+		deployments.Deployments = append(deployments.Deployments, deployment)
+	}
+}
+
+// recordDeployment adds the deployment to the registry of the chain the package is run against,
+// which is skipped when the id of the chain could not be read, as saving the abis is
+func recordDeployment(do *definitions.Do, deployment *Deployment) error {
+	if do.ChainID == "" {
+		log.WithField("=>", deployment.Contract).Warn("Chain ID Unknown, Not Recording Deployment")
+		return nil
+	}
+	deployments, file, err := loadDeployments(do)
+	if err != nil {
+		return err
+	}
+	deployments.record(deployment)
+	log.WithField("=>", file).Debug("Recording Deployment")
+	return deployments.write(file)
+}
+
+// unchangedDeployment returns the address a job deployed the contract to before if the bytecode,
+// abi and constructor arguments are unchanged and the contract is still on the chain
+func unchangedDeployment(do *definitions.Do, deployment *Deployment) (string, error) {
+	deployments, _, err := loadDeployments(do)
+	if err != nil {
+		return "", err
+	}
+	recorded := deployments.find(deployment.Job, deployment.Contract)
+	if recorded == nil {
+		log.WithField("=>", deployment.Contract).Info("No Previous Deployment")
+		return "", nil
+	}
+	if recorded.BytecodeHash != deployment.BytecodeHash || recorded.ABIHash != deployment.ABIHash ||
+		recorded.Args != deployment.Args {
+		log.WithField("=>", deployment.Contract).Info("Contract Changed Since Last Deployment")
+		return "", nil
+	}
+
+	address, err := acm.AddressFromHexString(strings.TrimPrefix(recorded.Address, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid address %s recorded for %s: %v", recorded.Address, deployment.Contract, err)
+	}
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	account, err := nodeClient.GetAccount(address)
+	if err != nil || len(account.Code()) == 0 {
+		log.WithField("=>", recorded.Address).Info("Previously Deployed Contract Not On Chain")
+		return "", nil
+	}
+	return recorded.Address, nil
+}
+
+// committed completes a deployment with the outcome of the deploy transaction
+func (deployment *Deployment) committed(res *txResult) *Deployment {
+	deployment.Address = res.Address.String()
+	deployment.TxHash = fmt.Sprintf("%X", res.Hash)
+	deployment.BlockHeight = res.BlockHeight
+	return deployment
+}
+
+// skipDeployment returns the address of the previous deployment when the deploy job should skip
+// deploying an unchanged contract again
+func skipDeployment(deploy *definitions.Deploy, do *definitions.Do, deployment *Deployment) (string, error) {
+	if !deploy.SkipIfUnchanged {
+		return "", nil
+	}
+	address, err := unchangedDeployment(do, deployment)
+	if err != nil {
+		return "", err
+	}
+	if address != "" {
+		log.WithFields(log.Fields{
+			"contract": deployment.Contract,
+			"address":  address,
+		}).Warn("Contract Unchanged, Skipping Deploy")
+	}
+	return address, nil
+}
+
+// skippedVariables are the variables of a deploy job which skipped deploying an unchanged contract,
+// the same as those of a deployment but for no events being emitted and no gas being used. Events
+// are only listed when there is an abi
+func skippedVariables(abiData string, linked map[string]string) []*definitions.Variable {
+	var variables []*definitions.Variable
+	if abiData != "" {
+		variables = eventVariables(abiData, nil, "", nil)
+	}
+	variables = append(variables, libraryVariables(linked)...)
+	return append(variables, gasVariables(0, &txResult{})...)
+}
+
+// deployJobName finds the name of the job a deploy belongs to, deployments are recorded by job
+func deployJobName(deploy *definitions.Deploy, do *definitions.Do) string {
+	for _, job := range do.Package.Jobs {
+		if job.Deploy == deploy {
+			return job.JobName
+		}
+	}
+	return ""
+}
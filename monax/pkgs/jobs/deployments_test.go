@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+func TestDeploymentsRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deployments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "deployments", "marmot-chain.json")
+
+	deployments, err := readDeployments(file, "marmot-chain")
+	if err != nil {
+		t.Fatalf("expected a missing registry to be empty, got %v", err)
+	}
+	if len(deployments.Deployments) != 0 {
+		t.Fatalf("expected a missing registry to be empty, got %v", deployments.Deployments)
+	}
+
+	deployments.record(&Deployment{Job: "deployToken", Contract: "Token", Address: "1040E6521541DAA7E2D8B0B8D68A46D9C8A7E0B5"})
+	deployments.record(&Deployment{Job: "deployToken", Contract: "Search", Address: "58FD1799AA32DED3F6EAC096A1DC77834A446B9C"})
+	deployments.record(&Deployment{Job: "deployToken", Contract: "Token", Address: "6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC", Args: "0A"})
+	if err := deployments.write(file); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readDeployments(file, "marmot-chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Deployments) != 2 {
+		t.Fatalf("expected the redeploy of Token to replace the first, got %d deployments", len(read.Deployments))
+	}
+	token := read.find("deployToken", "Token")
+	if token == nil || token.Address != "6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC" || token.Args != "0A" {
+		t.Errorf("unexpected deployment of Token %v", token)
+	}
+	if read.find("deployOther", "Token") != nil {
+		t.Errorf("expected deployments to be recorded by job")
+	}
+
+	// the registry is that of the chain whose id was read when the package started running
+	do := definitions.NowDo()
+	do.DeploymentsPath = filepath.Join(dir, "deployments")
+	do.ChainID = "marmot-chain"
+	if _, err := deploymentsFile(do); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordDeployment(do, &Deployment{Job: "deployOther", Contract: "Token"}); err != nil {
+		t.Fatal(err)
+	}
+	if read, _ = readDeployments(file, "marmot-chain"); read.find("deployOther", "Token") == nil {
+		t.Errorf("expected the deployment to be recorded in the registry of the chain")
+	}
+	do.ChainID = ""
+	if _, err := deploymentsFile(do); err == nil {
+		t.Errorf("expected an error for the registry of a chain whose id is not known")
+	}
+	if err := recordDeployment(do, &Deployment{Job: "deployOther", Contract: "Token"}); err != nil {
+		t.Errorf("expected a deployment to be skipped when the chain id is not known, got %v", err)
+	}
+}
+
+func TestSkippedVariables(t *testing.T) {
+	abiData := `[{"anonymous":false,"inputs":[],"name":"Created","type":"event"}]`
+	values := variableValues(skippedVariables(abiData, nil))
+	if values["events"] != "[]" || values["gas.used"] != "0" {
+		t.Errorf("expected no events and no gas used for a skipped deploy, got %v", values)
+	}
+	if _, ok := variableValues(skippedVariables("", nil))["events"]; ok {
+		t.Errorf("expected no events variable without an abi")
+	}
+}
+
+func variableValues(vars []*definitions.Variable) map[string]string {
+	values := make(map[string]string)
+	for _, v := range vars {
+		values[v.Name] = v.Value
+	}
+	return values
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+
+	evm_events "github.com/hyperledger/burrow/execution/evm/events"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// eventVariables decodes the logs of a transaction with the events of the contract's abi and turns
+// them into job variables. The variable "events" lists the names of the emitted events in order,
+// e.g. [Transfer,Approval], which is what the emitted relation of the assert job checks against.
+// The arguments of each event are available as events.<name>[<n>].<argument>, where n counts
+// the emissions of that event by the transaction, e.g. $call.events.Transfer[0].value.
+// Logs emitted by other contracts the transaction called are decoded with the abi stored for the
+// address of the emitting contract when the abi does not match them, which needs do. Logs which
+// match no event are skipped. Bytes are given in the encoding.
+func eventVariables(abiData string, logs []*evm_events.EventDataLog, encoding string,
+	do *definitions.Do) []*definitions.Variable {
+	var names []string
+	var vars []*definitions.Variable
+	emitted := make(map[string]int)
+	for _, eventLog := range logs {
+		topics := make([][]byte, len(eventLog.Topics))
+		for i, topic := range eventLog.Topics {
+			topics[i] = topic.Bytes()
+		}
+		name, args, err := abi.UnpackEvent(abiData, topics, eventLog.Data, encoding)
+		if err != nil && do != nil {
+			if emitterABI, abiErr := util.ReadAbi(do, eventLog.Address.String()); abiErr == nil {
+				name, args, err = abi.UnpackEvent(emitterABI, topics, eventLog.Data, encoding)
+			}
+		}
+		if err != nil {
+			log.WithField("=>", err).Warn("Could not decode event log")
+			continue
+		}
+
+		prefix := fmt.Sprintf("events.%s[%d]", name, emitted[name])
+		emitted[name]++
+		names = append(names, name)
+		for _, arg := range args {
+			log.WithField("=>", fmt.Sprintf("%s.%s,%s", prefix, arg.Name, arg.Value)).Info("Event")
+			vars = append(vars, &definitions.Variable{
+				Name:  prefix + "." + arg.Name,
+				Value: arg.Value,
+			})
+		}
+	}
+
+	return append([]*definitions.Variable{{
+		Name:  "events",
+		Value: "[" + strings.Join(names, ",") + "]",
+	}}, vars...)
+}
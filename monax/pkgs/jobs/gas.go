@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+)
+
+// autoGas is the gas setting which has the gas of a call or deploy estimated by simulating it
+const autoGas = "auto"
+
+// fallbackGas is given to transactions we cannot simulate because they are expected to revert
+const fallbackGas = "1111111111"
+
+// estimateGas simulates a call (or a deploy when destination is empty, in which case data is the
+// contract code) against the current state of the chain and returns the gas used scaled up by the
+// safety multiplier, along with the unscaled estimate
+func estimateGas(do *definitions.Do, source, destination, data, expect string) (string, uint64, error) {
+	if do.GasMultiplier < 1 {
+		return "", 0, fmt.Errorf("the gas multiplier must be at least 1, got %v", do.GasMultiplier)
+	}
+	from, err := acm.AddressFromHexString(strings.TrimPrefix(source, "0x"))
+	if err != nil {
+		return "", 0, fmt.Errorf("could not estimate gas, invalid source address %s: %v", source, err)
+	}
+	input, err := hex.DecodeString(data)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not estimate gas, invalid data %s: %v", data, err)
+	}
+
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	var gasUsed uint64
+	if destination == "" {
+		_, gasUsed, err = nodeClient.QueryContractCode(from, input, nil)
+	} else {
+		var to acm.Address
+		to, err = acm.AddressFromHexString(strings.TrimPrefix(destination, "0x"))
+		if err != nil {
+			return "", 0, fmt.Errorf("could not estimate gas, invalid destination address %s: %v", destination, err)
+		}
+		_, gasUsed, err = nodeClient.QueryContract(from, to, input)
+	}
+	if err != nil {
+		if expect == "revert" {
+			log.WithField("=>", fallbackGas).Warn("Simulation Reverted As Expected, Using Fallback Gas")
+			return fallbackGas, 0, nil
+		}
+		return "", 0, fmt.Errorf("could not estimate gas, simulating the transaction failed: %v", err)
+	}
+
+	gas := uint64(math.Ceil(float64(gasUsed) * do.GasMultiplier))
+	log.WithFields(log.Fields{
+		"estimated":  gasUsed,
+		"multiplier": do.GasMultiplier,
+		"gas":        gas,
+	}).Info("Estimated Gas")
+	return strconv.FormatUint(gas, 10), gasUsed, nil
+}
+
+// gasVariables reports the gas a transaction used, and the estimate when the gas was estimated, as
+// job variables ($job.gas.used and $job.gas.estimated) which are also written to the jobs output
+func gasVariables(estimated uint64, res *txResult) []*definitions.Variable {
+	vars := []*definitions.Variable{{
+		Name:  "gas.used",
+		Value: strconv.FormatUint(res.GasUsed, 10),
+	}}
+	fields := log.Fields{"used": res.GasUsed}
+	if estimated != 0 {
+		vars = append(vars, &definitions.Variable{
+			Name:  "gas.estimated",
+			Value: strconv.FormatUint(estimated, 10),
+		})
+		fields["estimated"] = estimated
+	}
+	log.WithFields(fields).Warn("Gas")
+	return vars
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+func TestGasVariables(t *testing.T) {
+	res := &txResult{TxResult: &rpc.TxResult{}, GasUsed: 21000}
+
+	vars := gasVariables(0, res)
+	if len(vars) != 1 || vars[0].Name != "gas.used" || vars[0].Value != "21000" {
+		t.Errorf("expected only gas.used=21000 without an estimate, got %v", vars)
+	}
+
+	vars = gasVariables(20000, res)
+	if len(vars) != 2 || vars[1].Name != "gas.estimated" || vars[1].Value != "20000" {
+		t.Errorf("expected gas.estimated=20000 with an estimate, got %v", vars)
+	}
+}
+
+func TestEstimateGasBadMultiplier(t *testing.T) {
+	do := definitions.NowDo()
+	do.GasMultiplier = 0.5
+	if _, _, err := estimateGas(do, "", "", "", ""); err == nil {
+		t.Errorf("expected a gas multiplier below 1 to be refused")
+	}
+}
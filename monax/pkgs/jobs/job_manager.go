@@ -23,6 +23,10 @@ func RunJobs(do *definitions.Do) error {
 		defaultAddrJob(do)
 	}
 
+	// the receipts and contracts of an earlier run are not those of this package
+	receipts = make(map[string]*txResult)
+	deployedContracts = make(map[string]string)
+
 	for index, job := range do.Package.Jobs {
 		for _, checkForDup := range do.Package.Jobs[0:index] {
 			if checkForDup.JobName == job.JobName {
@@ -72,7 +76,7 @@ func RunJobs(do *definitions.Do) error {
 		// Contracts jobs
 		case job.Deploy != nil:
 			announce(job.JobName, "Deploy")
-			job.JobResult, err = DeployJob(job.Deploy, do)
+			job.JobResult, job.JobVars, err = DeployJob(job.Deploy, do)
 		case job.Call != nil:
 			announce(job.JobName, "Call")
 			job.JobResult, job.JobVars, err = CallJob(job.Call, do)
@@ -81,6 +85,15 @@ func RunJobs(do *definitions.Do) error {
 					log.WithField("=>", fmt.Sprintf("%s,%s", theJob.Name, theJob.Value)).Info("Job Vars")
 				}
 			}
+		case job.Proxy != nil:
+			announce(job.JobName, "Proxy")
+			job.JobResult, job.JobVars, err = ProxyJob(job.Proxy, do)
+		case job.Upgrade != nil:
+			announce(job.JobName, "Upgrade")
+			job.JobResult, job.JobVars, err = UpgradeJob(job.Upgrade, do)
+		case job.Verify != nil:
+			announce(job.JobName, "Verify")
+			job.JobResult, err = VerifyJob(job.Verify, do)
 		// State jobs
 		case job.RestoreState != nil:
 			announce(job.JobName, "RestoreState")
@@ -107,12 +120,30 @@ func RunJobs(do *definitions.Do) error {
 		case job.QueryVals != nil:
 			announce(job.JobName, "QueryVals")
 			job.JobResult, err = QueryValsJob(job.QueryVals, do)
+		case job.QueryStorage != nil:
+			announce(job.JobName, "QueryStorage")
+			job.JobResult, err = QueryStorageJob(job.QueryStorage, do)
+		case job.QueryTx != nil:
+			announce(job.JobName, "QueryTx")
+			job.JobResult, job.JobVars, err = QueryTxJob(job.QueryTx, do)
+		case job.QueryBlock != nil:
+			announce(job.JobName, "QueryBlock")
+			job.JobResult, job.JobVars, err = QueryBlockJob(job.QueryBlock, do)
 		case job.Assert != nil:
 			announce(job.JobName, "Assert")
 			job.JobResult, err = AssertJob(job.Assert, do)
 		}
 
 		if err != nil {
+			if revert, ok := err.(*revertError); ok {
+				// keep what the contract returned so the reason can be decoded later
+				job.JobResult = fmt.Sprintf("%X", revert.Return)
+				if outputErr := postProcess(do); outputErr != nil {
+					return fmt.Errorf("job %s failed, %v, and its output could not be written: %v", job.JobName,
+						err, outputErr)
+				}
+				return fmt.Errorf("job %s failed, %v", job.JobName, err)
+			}
 			return err
 		}
 	}
@@ -180,8 +211,12 @@ func postProcess(do *definitions.Do) error {
 	results := make(map[string]string)
 	for _, job := range do.Package.Jobs {
 		results[job.JobName] = job.JobResult
+		// report the gas of transactions and linked libraries alongside their results
+		for _, variable := range job.JobVars {
+			if strings.HasPrefix(variable.Name, "gas.") || strings.HasPrefix(variable.Name, "libraries.") {
+				results[job.JobName+"."+variable.Name] = variable.Value
+			}
+		}
 	}
 	return WriteJobResultJSON(results, do.DefaultOutput)
-
-	return nil
 }
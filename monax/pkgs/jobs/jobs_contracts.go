@@ -20,16 +20,26 @@ import (
 	"github.com/monax/bosmarmot/monax/util"
 )
 
-func DeployJob(deploy *definitions.Deploy, do *definitions.Do) (result string, err error) {
+func DeployJob(deploy *definitions.Deploy, do *definitions.Do) (string, []*definitions.Variable, error) {
+	return deployJob(deployJobName(deploy, do), deploy, do)
+}
+
+// deployJob runs a deploy on behalf of a job, the deployments are recorded under the job's name
+func deployJob(jobName string, deploy *definitions.Deploy, do *definitions.Do) (result string, variables []*definitions.Variable, err error) {
 	// Preprocess variables
 	deploy.Source, _ = util.PreProcess(deploy.Source, do)
 	deploy.Contract, _ = util.PreProcess(deploy.Contract, do)
 	deploy.Instance, _ = util.PreProcess(deploy.Instance, do)
 	deploy.Libraries, _ = util.PreProcessLibs(deploy.Libraries, do)
-	deploy.Amount, _ = util.PreProcess(deploy.Amount, do)
+	if err := preProcessNumbers(do, &deploy.Amount, &deploy.Fee, &deploy.Gas); err != nil {
+		return "", nil, err
+	}
 	deploy.Nonce, _ = util.PreProcess(deploy.Nonce, do)
-	deploy.Fee, _ = util.PreProcess(deploy.Fee, do)
-	deploy.Gas, _ = util.PreProcess(deploy.Gas, do)
+	deploy.Expect, _ = util.PreProcess(deploy.Expect, do)
+	deploy.ExpectReason, _ = util.PreProcess(deploy.ExpectReason, do)
+	if err := checkExpect(deploy.Expect); err != nil {
+		return "", nil, err
+	}
 
 	// trim the extension
 	contractName := strings.TrimSuffix(deploy.Contract, filepath.Ext(deploy.Contract))
@@ -45,7 +55,7 @@ func DeployJob(deploy *definitions.Deploy, do *definitions.Do) (result string, e
 	var contractPath string
 	if _, err := os.Stat(deploy.Contract); err != nil {
 		if _, secErr := os.Stat(filepath.Join(do.BinPath, deploy.Contract)); secErr != nil {
-			return "", fmt.Errorf("Could not find contract in %v or in binary path %v", deploy.Contract, do.BinPath)
+			return "", nil, fmt.Errorf("Could not find contract in %v or in binary path %v", deploy.Contract, do.BinPath)
 		}
 	}
 
@@ -63,22 +73,72 @@ func DeployJob(deploy *definitions.Deploy, do *definitions.Do) (result string, e
 		log.WithField("=>", contractPath).Info("Binary path")
 		binaryResponse, err := compilers.RequestBinaryLinkage(contractPath, deploy.Libraries)
 		if err != nil {
-			return "", fmt.Errorf("Something went wrong with your binary deployment: %v", err)
+			return "", nil, fmt.Errorf("Something went wrong with your binary deployment: %v", err)
 		}
 		if binaryResponse.Error != "" {
-			return "", fmt.Errorf("Something went wrong when you were trying to link your binaries: %v", binaryResponse.Error)
+			return "", nil, fmt.Errorf("Something went wrong when you were trying to link your binaries: %v", binaryResponse.Error)
+		}
+		// binaries can only be linked to libraries which are already deployed
+		contractCode, linked, err := linkLibraries(jobName, deploy, do, binaryResponse.Binary, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		// binaries come without an abi, but one may have been saved for the contract earlier
+		abiData, abiErr := util.ReadAbi(do, contractName)
+
+		deployment := &Deployment{
+			Job:          jobName,
+			Contract:     contractName,
+			BytecodeHash: hashHex(contractCode),
+			ABIHash:      hashHex(abiData),
+		}
+		if address, err := skipDeployment(deploy, do, deployment); err != nil {
+			return "", nil, err
+		} else if address != "" {
+			if abiErr != nil {
+				abiData = ""
+			} else if err := util.NewABIStore(do.ABIPath, do.ChainID).SaveAddress(address, abiData); err != nil {
+				return "", nil, err
+			}
+			return address, skippedVariables(abiData, linked), nil
 		}
-		contractCode := binaryResponse.Binary
 
-		tx, err := deployRaw(do, deploy, contractName, string(contractCode))
+		tx, estimated, err := deployRaw(do, deploy, contractName, string(contractCode))
 		if err != nil {
-			return "could not deploy binary contract", err
+			return "could not deploy binary contract", nil, err
+		}
+		res, err := deployFinalize(do, deploy, abiData, tx)
+		if _, ok := err.(*revertError); ok {
+			return "", nil, err
+		} else if err != nil {
+			return "", nil, fmt.Errorf("Error finalizing contract deploy from path %s: %v", contractPath, err)
+		}
+		if res.Exception != "" {
+			return res.Exception, nil, nil
+		}
+		if err := recordDeployment(do, deployment.committed(res)); err != nil {
+			return "", nil, err
 		}
-		result, err := deployFinalize(do, tx)
+		if abiErr == nil {
+			variables = eventVariables(abiData, res.Logs, do.BytesEncoding, do)
+		}
+		variables = append(variables, libraryVariables(linked)...)
+		variables = append(variables, gasVariables(estimated, res)...)
+		return res.Address.String(), variables, nil
+	} else if filepath.Ext(deploy.Contract) == ".json" {
+		contractPath = deploy.Contract
+		if _, err := os.Stat(contractPath); err != nil {
+			contractPath = filepath.Join(do.BinPath, deploy.Contract)
+		}
+		log.WithField("=>", contractPath).Info("Artifact path")
+		objects, err := readArtifacts(contractPath)
 		if err != nil {
-			return "", fmt.Errorf("Error finalizing contract deploy from path %s: %v", contractPath, err)
+			return "", nil, err
+		}
+		result, variables, err = deployObjects(jobName, deploy, do, contractPath, objects)
+		if err != nil {
+			return "", nil, err
 		}
-		return result, err
 	} else {
 		contractPath = deploy.Contract
 		log.WithField("=>", contractPath).Info("Contract path")
@@ -87,57 +147,16 @@ func DeployJob(deploy *definitions.Deploy, do *definitions.Do) (result string, e
 
 		if err != nil {
 			log.Errorln("Error compiling contracts: Compilers error:")
-			return "", err
+			return "", nil, err
 		} else if resp.Error != "" {
 			log.Errorln("Error compiling contracts: Language error:")
-			return "", fmt.Errorf("%v", resp.Error)
+			return "", nil, fmt.Errorf("%v", resp.Error)
 		} else if resp.Warning != "" {
 			log.WithField("Warning", resp.Warning).Warn("Warning Generated during Contract Compilation")
 		}
-		// loop through objects returned from compiler
-		switch {
-		case len(resp.Objects) == 1:
-			log.WithField("path", contractPath).Info("Deploying the only contract in file")
-			response := resp.Objects[0]
-			log.WithField("=>", response.ABI).Info("Abi")
-			log.WithField("=>", response.Bytecode).Info("Bin")
-			if response.Bytecode != "" {
-				result, err = deployContract(deploy, do, response)
-				if err != nil {
-					return "", err
-				}
-			}
-		case deploy.Instance == "all":
-			log.WithField("path", contractPath).Info("Deploying all contracts")
-			var baseObj string
-			for _, response := range resp.Objects {
-				if response.Bytecode == "" {
-					continue
-				}
-				result, err = deployContract(deploy, do, response)
-				if err != nil {
-					return "", err
-				}
-				if strings.ToLower(response.Objectname) == strings.ToLower(strings.TrimSuffix(filepath.Base(deploy.Contract), filepath.Ext(filepath.Base(deploy.Contract)))) {
-					baseObj = result
-				}
-			}
-			if baseObj != "" {
-				result = baseObj
-			}
-		default:
-			log.WithField("contract", deploy.Instance).Info("Deploying a single contract")
-			for _, response := range resp.Objects {
-				if response.Bytecode == "" {
-					continue
-				}
-				if matchInstanceName(response.Objectname, deploy.Instance) {
-					result, err = deployContract(deploy, do, response)
-					if err != nil {
-						return "", err
-					}
-				}
-			}
+		result, variables, err = deployObjects(jobName, deploy, do, contractPath, resp.Objects)
+		if err != nil {
+			return "", nil, err
 		}
 	}
 
@@ -146,7 +165,62 @@ func DeployJob(deploy *definitions.Deploy, do *definitions.Do) (result string, e
 		do.PublicKey = oldKey
 	}
 
-	return result, nil
+	return result, variables, nil
+}
+
+// deployObjects deploys the contract of the instance, or all of them, from the objects the compilers
+// returned or an artifact held
+func deployObjects(jobName string, deploy *definitions.Deploy, do *definitions.Do, contractPath string,
+	objects []compilers.ResponseItem) (result string, variables []*definitions.Variable, err error) {
+	// loop through objects returned from compiler
+	switch {
+	case len(objects) == 1:
+		log.WithField("path", contractPath).Info("Deploying the only contract in file")
+		response := objects[0]
+		log.WithField("=>", response.ABI).Info("Abi")
+		log.WithField("=>", response.Bytecode).Info("Bin")
+		if response.Bytecode != "" {
+			result, variables, err = deployContract(jobName, deploy, do, response, objects)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	case deploy.Instance == "all":
+		log.WithField("path", contractPath).Info("Deploying all contracts")
+		var baseObj string
+		var baseVars []*definitions.Variable
+		for _, response := range objects {
+			if response.Bytecode == "" {
+				continue
+			}
+			result, variables, err = deployContract(jobName, deploy, do, response, objects)
+			if err != nil {
+				return "", nil, err
+			}
+			if strings.ToLower(contractName(response.Objectname)) == strings.ToLower(strings.TrimSuffix(filepath.Base(deploy.Contract), filepath.Ext(filepath.Base(deploy.Contract)))) {
+				baseObj = result
+				baseVars = variables
+			}
+		}
+		if baseObj != "" {
+			result = baseObj
+			variables = baseVars
+		}
+	default:
+		log.WithField("contract", deploy.Instance).Info("Deploying a single contract")
+		for _, response := range objects {
+			if response.Bytecode == "" {
+				continue
+			}
+			if matchInstanceName(response.Objectname, deploy.Instance) {
+				result, variables, err = deployContract(jobName, deploy, do, response, objects)
+				if err != nil {
+					return "", nil, err
+				}
+			}
+		}
+	}
+	return result, variables, nil
 }
 
 func matchInstanceName(objectName, deployInstance string) bool {
@@ -154,80 +228,123 @@ func matchInstanceName(objectName, deployInstance string) bool {
 		return false
 	}
 	// Ignore the filename component that newer versions of Solidity include in object name
+	return strings.ToLower(contractName(objectName)) == strings.ToLower(deployInstance)
+}
+
+// contractName is the contract of an object named file:contract, as those of artifacts are
+func contractName(objectName string) string {
 	objectNameParts := strings.Split(objectName, ":")
-	return strings.ToLower(objectNameParts[len(objectNameParts)-1]) == strings.ToLower(deployInstance)
+	return objectNameParts[len(objectNameParts)-1]
+}
+
+// qualifiedName is the name the abi of a deployed object is saved under: the file:contract it is
+// named in an artifact, or the contract in the file of the deploy when compiled
+func qualifiedName(do *definitions.Do, deploy *definitions.Deploy, objectName string) string {
+	if strings.Contains(objectName, ":") {
+		return objectName
+	}
+	return util.QualifiedName(do, deploy.Contract, objectName)
 }
 
 // TODO [rj] refactor to remove [contractPath] from functions signature => only used in a single error throw.
-func deployContract(deploy *definitions.Deploy, do *definitions.Do, compilersResponse compilers.ResponseItem) (string, error) {
+func deployContract(jobName string, deploy *definitions.Deploy, do *definitions.Do, compilersResponse compilers.ResponseItem,
+	objects []compilers.ResponseItem) (string, []*definitions.Variable, error) {
 	log.WithField("=>", string(compilersResponse.ABI)).Debug("ABI Specification (From Compilers)")
-	contractCode := compilersResponse.Bytecode
 
-	// Save ABI
-	if _, err := os.Stat(do.ABIPath); os.IsNotExist(err) {
-		if err := os.Mkdir(do.ABIPath, 0775); err != nil {
-			return "", err
-		}
+	// link (and deploy if need be) the libraries which solc left placeholders for
+	contractCode, linked, err := linkLibraries(jobName, deploy, do, compilersResponse.Bytecode, objects)
+	if err != nil {
+		return "", nil, err
 	}
+
 	if _, err := os.Stat(do.BinPath); os.IsNotExist(err) {
 		if err := os.Mkdir(do.BinPath, 0775); err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
-	// saving contract/library abi
-	var abiLocation string
+	// saving contract/library abi under file:contract
+	abiStore := util.NewABIStore(do.ABIPath, do.ChainID)
+	abiName := qualifiedName(do, deploy, compilersResponse.Objectname)
 	if compilersResponse.Objectname != "" {
-		abiLocation = filepath.Join(do.ABIPath, compilersResponse.Objectname)
-		log.WithField("=>", abiLocation).Warn("Saving ABI")
-		if err := ioutil.WriteFile(abiLocation, []byte(compilersResponse.ABI), 0664); err != nil {
-			return "", err
+		log.WithField("=>", abiName).Warn("Saving ABI")
+		if _, err := abiStore.Save(abiName, compilersResponse.ABI); err != nil {
+			return "", nil, err
 		}
 	} else {
 		log.Debug("Objectname from compilers is blank. Not saving abi.")
 	}
 
+	deployment := &Deployment{
+		Job:          jobName,
+		Contract:     contractName(compilersResponse.Objectname),
+		BytecodeHash: hashHex(contractCode),
+		ABIHash:      hashHex(compilersResponse.ABI),
+	}
+
 	// additional data may be sent along with the contract
 	// these are naively added to the end of the contract code using standard
 	// mint packing
 
 	if deploy.Data != nil {
-		_, callDataArray, err := util.PreProcessInputData(compilersResponse.Objectname, deploy.Data, do, true)
+		_, callDataArray, err := abi.PreProcessInputData(abiName, compilersResponse.Objectname, deploy.Data, do, true)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
-		packedBytes, err := abi.ReadAbiFormulateCall(compilersResponse.Objectname, "", callDataArray, do)
+		packedBytes, err := abi.ReadAbiFormulateCall(abiName, "", callDataArray, do)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		callData := hex.EncodeToString(packedBytes)
 		contractCode = contractCode + callData
+		deployment.Args = callData
 	}
 
-	tx, err := deployRaw(do, deploy, compilersResponse.Objectname, contractCode)
+	if address, err := skipDeployment(deploy, do, deployment); err != nil {
+		return "", nil, err
+	} else if address != "" {
+		// calls to the contract find its abi by address, which may not have been saved on this machine
+		if err := abiStore.SaveAddress(address, compilersResponse.ABI); err != nil {
+			return "", nil, err
+		}
+		deployedContracts[strings.ToLower(contractName(compilersResponse.Objectname))] = address
+		return address, skippedVariables(compilersResponse.ABI, linked), nil
+	}
+
+	tx, estimated, err := deployRaw(do, deploy, contractName(compilersResponse.Objectname), contractCode)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Sign, broadcast, display
-	result, err := deployFinalize(do, tx)
-	if err != nil {
-		return "", fmt.Errorf("Error finalizing contract deploy %s: %v", deploy.Contract, err)
+	res, err := deployFinalize(do, deploy, compilersResponse.ABI, tx)
+	if _, ok := err.(*revertError); ok {
+		return "", nil, err
+	} else if err != nil {
+		return "", nil, fmt.Errorf("Error finalizing contract deploy %s: %v", deploy.Contract, err)
 	}
+	if res.Exception != "" {
+		// reverted as expected, so there is nothing deployed to save
+		return res.Exception, nil, nil
+	}
+	result := res.Address.String()
+	if err := recordDeployment(do, deployment.committed(res)); err != nil {
+		return "", nil, err
+	}
+	deployedContracts[strings.ToLower(contractName(compilersResponse.Objectname))] = result
 
-	// saving contract/library abi at abi/address
+	// saving contract/library abi under the address on this chain
 	if result != "" {
-		abiLocation := filepath.Join(do.ABIPath, result)
-		log.WithField("=>", abiLocation).Debug("Saving ABI")
-		if err := ioutil.WriteFile(abiLocation, []byte(compilersResponse.ABI), 0664); err != nil {
-			return "", err
+		log.WithField("=>", result).Debug("Saving ABI")
+		if err := abiStore.SaveAddress(result, compilersResponse.ABI); err != nil {
+			return "", nil, err
 		}
 		// saving binary
 		if deploy.SaveBinary {
-			contractName := filepath.Join(do.BinPath, fmt.Sprintf("%s.bin", compilersResponse.Objectname))
-			log.WithField("=>", contractName).Warn("Saving Binary")
-			if err := ioutil.WriteFile(contractName, []byte(contractCode), 0664); err != nil {
-				return "", err
+			binName := filepath.Join(do.BinPath, fmt.Sprintf("%s.bin", contractName(compilersResponse.Objectname)))
+			log.WithField("=>", binName).Warn("Saving Binary")
+			if err := ioutil.WriteFile(binName, []byte(contractCode), 0664); err != nil {
+				return "", nil, err
 			}
 		} else {
 			log.Debug("Not saving binary.")
@@ -237,10 +354,14 @@ func deployContract(deploy *definitions.Deploy, do *definitions.Do, compilersRes
 		log.Error("The contract did not deploy. Unable to save abi to abi/contractAddress.")
 	}
 
-	return result, err
+	variables := eventVariables(compilersResponse.ABI, res.Logs, do.BytesEncoding, do)
+	variables = append(variables, libraryVariables(linked)...)
+	variables = append(variables, gasVariables(estimated, res)...)
+	return result, variables, nil
 }
 
-func deployRaw(do *definitions.Do, deploy *definitions.Deploy, contractName, contractCode string) (*txs.CallTx, error) {
+// deployRaw formulates the deploy transaction, it returns the gas estimate when the gas is auto
+func deployRaw(do *definitions.Do, deploy *definitions.Deploy, contractName, contractCode string) (*txs.CallTx, uint64, error) {
 
 	// Deploy contract
 	log.WithFields(log.Fields{
@@ -253,15 +374,25 @@ func deployRaw(do *definitions.Do, deploy *definitions.Deploy, contractName, con
 		"chain-url": do.ChainURL,
 	}).Info()
 
+	gas := deploy.Gas
+	var estimated uint64
+	if gas == autoGas {
+		var err error
+		gas, estimated, err = estimateGas(do, deploy.Source, "", contractCode, deploy.Expect)
+		if err != nil {
+			return &txs.CallTx{}, 0, fmt.Errorf("error deploying contract %s: %v", contractName, err)
+		}
+	}
+
 	monaxNodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
 	monaxKeyClient := keys.NewKeyClient(do.Signer, loggers.NewNoopInfoTraceLogger())
 	tx, err := rpc.Call(monaxNodeClient, monaxKeyClient, do.PublicKey, deploy.Source, "", deploy.Amount,
-		deploy.Nonce, deploy.Gas, deploy.Fee, contractCode)
+		deploy.Nonce, gas, deploy.Fee, contractCode)
 	if err != nil {
-		return &txs.CallTx{}, fmt.Errorf("error deploying contract %s: %v", contractName, err)
+		return &txs.CallTx{}, 0, fmt.Errorf("error deploying contract %s: %v", contractName, err)
 	}
 
-	return tx, err
+	return tx, estimated, err
 }
 
 func CallJob(call *definitions.Call, do *definitions.Do) (string, []*definitions.Variable, error) {
@@ -271,40 +402,57 @@ func CallJob(call *definitions.Call, do *definitions.Do) (string, []*definitions
 	// Preprocess variables
 	call.Source, _ = util.PreProcess(call.Source, do)
 	call.Destination, _ = util.PreProcess(call.Destination, do)
-	//todo: find a way to call the fallback function here
-	call.Function, callDataArray, err = util.PreProcessInputData(call.Function, call.Data, do, false)
+	call.ABI, _ = util.PreProcess(call.ABI, do)
+	call.RawData, _ = util.PreProcess(call.RawData, do)
+	if call.RawData != "" {
+		callData, err = rawCallData(call.RawData, call.Function, call.Data)
+	} else if call.Function == fallbackFunction {
+		err = checkFallbackData(call.Data)
+	} else if call.Function, err = canonicalFunction(call.Function); err == nil {
+		call.Function, callDataArray, err = abi.PreProcessInputData(useDefault(call.ABI, call.Destination),
+			call.Function, call.Data, do, false)
+	}
 	if err != nil {
 		return "", nil, err
 	}
 	call.Function, _ = util.PreProcess(call.Function, do)
-	call.Amount, _ = util.PreProcess(call.Amount, do)
+	if err := preProcessNumbers(do, &call.Amount, &call.Fee, &call.Gas); err != nil {
+		return "", nil, err
+	}
 	call.Nonce, _ = util.PreProcess(call.Nonce, do)
-	call.Fee, _ = util.PreProcess(call.Fee, do)
-	call.Gas, _ = util.PreProcess(call.Gas, do)
-	call.ABI, _ = util.PreProcess(call.ABI, do)
+	call.Expect, _ = util.PreProcess(call.Expect, do)
+	call.ExpectReason, _ = util.PreProcess(call.ExpectReason, do)
+	call.BytesEncoding, _ = util.PreProcess(call.BytesEncoding, do)
+	if err := checkExpect(call.Expect); err != nil {
+		return "", nil, err
+	}
 
 	// Use default
 	call.Source = useDefault(call.Source, do.Package.Account)
 	call.Amount = useDefault(call.Amount, do.DefaultAmount)
 	call.Fee = useDefault(call.Fee, do.DefaultFee)
 	call.Gas = useDefault(call.Gas, do.DefaultGas)
-
-	// formulate call
-	var packedBytes []byte
-	if call.ABI == "" {
-		packedBytes, err = abi.ReadAbiFormulateCall(call.Destination, call.Function, callDataArray, do)
-		callData = hex.EncodeToString(packedBytes)
-	} else {
-		packedBytes, err = abi.ReadAbiFormulateCall(call.ABI, call.Function, callDataArray, do)
-		callData = hex.EncodeToString(packedBytes)
+	call.BytesEncoding = useDefault(call.BytesEncoding, do.BytesEncoding)
+	if err := abi.CheckBytesEncoding(call.BytesEncoding); err != nil {
+		return "", nil, err
 	}
-	if err != nil {
-		if call.Function == "()" {
-			log.Warn("Calling the fallback function")
+
+	// formulate call, the fallback function and raw data need no abi
+	rawReturn := call.RawData != "" || call.Function == fallbackFunction
+	if call.Function == fallbackFunction && call.RawData == "" {
+		log.Warn("Calling the fallback function")
+	} else if !rawReturn {
+		var packedBytes []byte
+		if call.ABI == "" {
+			packedBytes, err = abi.ReadAbiFormulateCall(call.Destination, call.Function, callDataArray, do)
 		} else {
+			packedBytes, err = abi.ReadAbiFormulateCall(call.ABI, call.Function, callDataArray, do)
+		}
+		if err != nil {
 			var str, err = util.ABIErrorHandler(do, err, call, nil)
 			return str, nil, err
 		}
+		callData = hex.EncodeToString(packedBytes)
 	}
 
 	// Don't use pubKey if account override
@@ -320,6 +468,14 @@ func CallJob(call *definitions.Call, do *definitions.Do) (string, []*definitions
 		"data":        callData,
 	}).Info("Calling")
 
+	var estimated uint64
+	if call.Gas == autoGas {
+		call.Gas, estimated, err = estimateGas(do, call.Source, call.Destination, callData, call.Expect)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
 	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
 	keyClient := keys.NewKeyClient(do.Signer, loggers.NewNoopInfoTraceLogger())
 	tx, err := rpc.Call(nodeClient, keyClient, do.PublicKey, call.Source, call.Destination, call.Amount, call.Nonce, call.Gas, call.Fee, callData)
@@ -333,15 +489,25 @@ func CallJob(call *definitions.Call, do *definitions.Do) (string, []*definitions
 	}
 
 	// Sign, broadcast, display
+	res, err := signAndBroadcast(do, tx)
+	if err != nil {
+		var str, err = util.MintChainErrorHandler(do, err)
+		return str, nil, err
+	}
 
-	_, chainID, _, err := nodeClient.ChainId()
+	// the abi decodes revert reasons and events
+	abiLocation := call.ABI
+	if abiLocation == "" {
+		abiLocation = call.Destination
+	}
+	abiData, abiErr := util.ReadAbi(do, abiLocation)
+
+	reverted, err := expectationMet(call.Expect, call.ExpectReason, abiData, res)
 	if err != nil {
 		return "", nil, err
 	}
-	res, err := rpc.SignAndBroadcast(chainID, nodeClient, keyClient, tx, true, true, true)
-	if err != nil {
-		var str, err = util.MintChainErrorHandler(do, err)
-		return str, nil, err
+	if reverted != "" {
+		return reverted, nil, nil
 	}
 
 	txResult := res.Return
@@ -349,12 +515,15 @@ func CallJob(call *definitions.Call, do *definitions.Do) (string, []*definitions
 	log.Debug(txResult)
 
 	// Formally process the return
-	if txResult != nil {
+	if rawReturn {
+		result = fmt.Sprintf("%X", txResult)
+		log.WithField("=>", result).Warn("Raw Return Value")
+	} else if txResult != nil {
 		log.WithField("=>", result).Debug("Decoding Raw Result")
 		if call.ABI == "" {
-			call.Variables, err = abi.ReadAndDecodeContractReturn(call.Destination, call.Function, txResult, do)
+			call.Variables, err = abi.ReadAndDecodeContractReturn(call.Destination, call.Function, txResult, call.BytesEncoding, do)
 		} else {
-			call.Variables, err = abi.ReadAndDecodeContractReturn(call.ABI, call.Function, txResult, do)
+			call.Variables, err = abi.ReadAndDecodeContractReturn(call.ABI, call.Function, txResult, call.BytesEncoding, do)
 		}
 		if err != nil {
 			return "", nil, err
@@ -375,27 +544,80 @@ func CallJob(call *definitions.Call, do *definitions.Do) (string, []*definitions
 		result = fmt.Sprintf("%X", res.Hash)
 	}
 
+	// decode the events emitted by the contract
+	if abiErr != nil {
+		log.WithField("=>", abiErr).Debug("No abi to decode events with")
+	} else {
+		call.Variables = append(call.Variables, eventVariables(abiData, res.Logs, call.BytesEncoding, do)...)
+	}
+	call.Variables = append(call.Variables, gasVariables(estimated, res)...)
+
 	return result, call.Variables, nil
 }
 
-func deployFinalize(do *definitions.Do, tx interface{}) (string, error) {
-	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
-	_, chainID, _, err := nodeClient.ChainId()
+// fallbackFunction is given as the function of a call to call the fallback function of the contract
+const fallbackFunction = "()"
+
+// checkFallbackData makes sure no data is given for the fallback function, which takes none, as it
+// would not be sent
+func checkFallbackData(data interface{}) error {
+	if list, ok := data.([]interface{}); data == nil || ok && len(list) == 0 {
+		return nil
+	}
+	return fmt.Errorf("data cannot be given when calling the fallback function, use raw-data to send it")
+}
+
+// canonicalFunction writes a function given by its signature, to pick one of its overloads, in
+// canonical form so transfer(address to, uint amount) is found as transfer(address,uint256). A
+// function given by name is left as is
+func canonicalFunction(function string) (string, error) {
+	if !strings.Contains(function, "(") || function == fallbackFunction {
+		return function, nil
+	}
+	fn, err := abi.ParseSignature(function)
 	if err != nil {
 		return "", err
 	}
-	keyClient := keys.NewKeyClient(do.Signer, loggers.NewNoopInfoTraceLogger())
-	res, err := rpc.SignAndBroadcast(chainID, nodeClient, keyClient, tx.(txs.Tx), true, true, true)
+	return fn.Signature()
+}
+
+// rawCallData checks the raw data of a call or query is hex encoded, which excludes giving the
+// function and its data, and returns it without a 0x prefix
+func rawCallData(rawData, function string, data interface{}) (string, error) {
+	if function != "" || data != nil {
+		return "", fmt.Errorf("raw-data cannot be given along with a function or data")
+	}
+	rawData = strings.TrimPrefix(rawData, "0x")
+	if _, err := hex.DecodeString(rawData); err != nil {
+		return "", fmt.Errorf("raw-data %s is not hex encoded: %v", rawData, err)
+	}
+	return rawData, nil
+}
+
+// deployFinalize signs and broadcasts a deploy. When the deploy reverted as expected the result's
+// Exception is replaced with the revert reason, if the contract gave one
+func deployFinalize(do *definitions.Do, deploy *definitions.Deploy, abiData string, tx *txs.CallTx) (*txResult, error) {
+	res, err := signAndBroadcast(do, tx)
 	if err != nil {
-		return util.MintChainErrorHandler(do, err)
+		_, err = util.MintChainErrorHandler(do, err)
+		return nil, err
 	}
 
-	if err := util.ReadTxSignAndBroadcast(res, err); err != nil {
-		return "", err
+	reverted, err := expectationMet(deploy.Expect, deploy.ExpectReason, abiData, res)
+	if err != nil {
+		return nil, err
+	}
+	if reverted != "" {
+		res.Exception = reverted
+		return res, nil
+	}
+
+	if err := util.ReadTxSignAndBroadcast(res.TxResult, err); err != nil {
+		return nil, err
 	}
 
 	if res.Address == nil {
-		return "", fmt.Errorf("result from SignAndBroadcast contains address for the deployed contract")
+		return nil, fmt.Errorf("result from SignAndBroadcast contains address for the deployed contract")
 	}
-	return res.Address.String(), nil
+	return res, nil
 }
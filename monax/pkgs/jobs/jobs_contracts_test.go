@@ -69,3 +69,60 @@ func Test_matchInstanceName(t *testing.T) {
 		})
 	}
 }
+
+func TestRawCallData(t *testing.T) {
+	for _, test := range []struct {
+		rawData, function string
+		data              interface{}
+		expected          string
+		fails             bool
+	}{
+		{"0xDEADBEEF", "", nil, "DEADBEEF", false},
+		{"cafe", "", nil, "cafe", false},
+		{"", "", nil, "", false},
+		{"0xdeadbee", "", nil, "", true},
+		{"0xmarmot", "", nil, "", true},
+		{"0xdeadbeef", "get", nil, "", true},
+		{"0xdeadbeef", "", []interface{}{1}, "", true},
+	} {
+		data, err := rawCallData(test.rawData, test.function, test.data)
+		if test.fails {
+			if err == nil {
+				t.Errorf("expected an error for raw-data %s with function %q and data %v", test.rawData, test.function, test.data)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error for raw-data %s: %v", test.rawData, err)
+		} else if data != test.expected {
+			t.Errorf("expected raw-data %s to be sent as %s, got %s", test.rawData, test.expected, data)
+		}
+	}
+}
+
+func TestCanonicalFunction(t *testing.T) {
+	for function, expected := range map[string]string{
+		"transfer":                            "transfer",
+		"()":                                  "()",
+		"transfer(address to, uint amount)":   "transfer(address,uint256)",
+		"place((uint,string)[] orders, bool)": "place((uint256,string)[],bool)",
+	} {
+		if canonical, err := canonicalFunction(function); err != nil || canonical != expected {
+			t.Errorf("expected %s to be written as %s, got %s: %v", function, expected, canonical, err)
+		}
+	}
+	if _, err := canonicalFunction("transfer(address"); err == nil {
+		t.Errorf("expected an error for a malformed signature")
+	}
+}
+
+func TestCheckFallbackData(t *testing.T) {
+	for _, data := range []interface{}{nil, []interface{}{}} {
+		if err := checkFallbackData(data); err != nil {
+			t.Errorf("unexpected error for %v: %v", data, err)
+		}
+	}
+	for _, data := range []interface{}{[]interface{}{1}, map[interface{}]interface{}{"a": 1}, "1"} {
+		if err := checkFallbackData(data); err == nil {
+			t.Errorf("expected an error for the fallback function with data %v", data)
+		}
+	}
+}
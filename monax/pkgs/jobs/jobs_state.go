@@ -1,17 +1,555 @@
 package jobs
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/hyperledger/burrow/permission"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	burrow_rpc "github.com/hyperledger/burrow/rpc"
+	"github.com/hyperledger/burrow/rpc/tm"
+	"github.com/hyperledger/burrow/txs"
 	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/util"
+	rpcclient "github.com/tendermint/tendermint/rpc/lib/client"
 )
 
+// the version of the state dump format, bumped whenever a change means older dumps cannot be read
+// as they are
+const stateDumpVersion = 1
+
+// StateDump is a snapshot of the state of a chain as of a block, written by dump-state jobs so that
+// it can be restored to another chain. Bytes (public keys, code and storage) are hex encoded.
+type StateDump struct {
+	Version     int                `json:"version"`
+	ChainID     string             `json:"chainId"`
+	BlockHeight uint64             `json:"blockHeight"`
+	Accounts    []*DumpedAccount   `json:"accounts"`
+	Names       []*DumpedName      `json:"names"`
+	Validators  []*DumpedValidator `json:"validators,omitempty"`
+}
+
+// DumpedAccount is an account along with the storage of its contract, when it has code. The public
+// key is empty for accounts which have received funds but never sent a transaction.
+type DumpedAccount struct {
+	Address     acm.Address               `json:"address"`
+	PublicKey   string                    `json:"publicKey,omitempty"`
+	Sequence    uint64                    `json:"sequence"`
+	Balance     uint64                    `json:"balance"`
+	Code        string                    `json:"code,omitempty"`
+	Storage     []*DumpedStorage          `json:"storage,omitempty"`
+	Permissions ptypes.AccountPermissions `json:"permissions"`
+}
+
+// DumpedStorage is a word of the storage of a contract
+type DumpedStorage struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// DumpedName is an entry of the name registry
+type DumpedName struct {
+	Name    string      `json:"name"`
+	Owner   acm.Address `json:"owner"`
+	Data    string      `json:"data"`
+	Expires uint64      `json:"expires"`
+}
+
+// DumpedValidator is a bonded validator, or one which is unbonding
+type DumpedValidator struct {
+	Address   acm.Address `json:"address"`
+	PublicKey string      `json:"publicKey"`
+	Power     uint64      `json:"power"`
+	Unbonding bool        `json:"unbonding,omitempty"`
+}
+
 func DumpStateJob(dump *definitions.DumpState, do *definitions.Do) (string, error) {
-	var result string
+	if dump.ToIPFS {
+		return "", fmt.Errorf("dumping state to IPFS is not supported, dump it to a file")
+	}
 
-	return result, nil
+	state, err := readState(do, dump.WithValidators)
+	if err != nil {
+		return "", err
+	}
+	file := dump.FilePath
+	if file == "" {
+		file = fmt.Sprintf("state-%s-%d.json", state.ChainID, state.BlockHeight)
+	}
+	log.WithFields(log.Fields{
+		"accounts": len(state.Accounts),
+		"names":    len(state.Names),
+		"height":   state.BlockHeight,
+	}).Info("Dumping state")
+	if err := state.write(file); err != nil {
+		return "", err
+	}
+	log.WithField("=>", file).Warn("State dumped to")
+	return file, nil
 }
 
 func RestoreStateJob(restore *definitions.RestoreState, do *definitions.Do) (string, error) {
-	var result string
+	if restore.FromIPFS {
+		return "", fmt.Errorf("restoring state from IPFS is not supported, restore it from a file")
+	}
+	file, _ := util.PreProcess(restore.FilePath, do)
+	if file == "" {
+		return "", fmt.Errorf("give the file written by a dump-state job to restore")
+	}
+	state, err := readStateDump(file)
+	if err != nil {
+		return "", err
+	}
+
+	var missed []string
+	switch restore.Mode {
+	case "", "genesis":
+		chainName := useDefault(restore.ChainName, state.ChainID)
+		genesisDoc, genesisMissed, err := stateGenesis(state, chainName, time.Now().UTC().Truncate(time.Second))
+		if err != nil {
+			return "", err
+		}
+		missed = genesisMissed
+		contents, err := genesisDoc.JSONBytes()
+		if err != nil {
+			return "", err
+		}
+		genesisFile := useDefault(restore.Genesis, "genesis.json")
+		if err := os.MkdirAll(filepath.Dir(genesisFile), 0775); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(genesisFile, contents, 0664); err != nil {
+			return "", err
+		}
+		log.WithField("=>", genesisFile).Warn("Genesis written to")
+	case "replay":
+		source, _ := util.PreProcess(restore.Source, do)
+		missed = replayState(state, useDefault(source, do.Package.Account), do)
+	default:
+		return "", fmt.Errorf("unknown restore mode %s, use genesis or replay", restore.Mode)
+	}
+
+	for _, miss := range missed {
+		log.WithField("=>", miss).Warn("Not restored")
+	}
+	return strconv.Itoa(len(missed)), nil
+}
+
+// readStateDump reads a file written by a dump-state job
+func readStateDump(file string) (*StateDump, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	state := new(StateDump)
+	if err := json.Unmarshal(contents, state); err != nil {
+		return nil, fmt.Errorf("could not read state dump %s: %v", file, err)
+	}
+	if state.Version < 1 || state.Version > stateDumpVersion {
+		return nil, fmt.Errorf("state dump %s is of version %d, only versions up to %d can be read", file,
+			state.Version, stateDumpVersion)
+	}
+	return state, nil
+}
+
+// stateGenesis makes a genesis document holding the accounts, with their balances and permissions,
+// and the bonded validators of a dump. It returns what a genesis cannot hold: the code and storage
+// of contracts and the names. Sequences start again from zero
+func stateGenesis(state *StateDump, chainName string, genesisTime time.Time) (*genesis.GenesisDoc, []string, error) {
+	genesisDoc := &genesis.GenesisDoc{
+		GenesisTime:       genesisTime,
+		ChainName:         chainName,
+		GlobalPermissions: permission.DefaultAccountPermissions,
+	}
+	var missed []string
+	for _, account := range state.Accounts {
+		if account.Address == permission.GlobalPermissionsAddress {
+			genesisDoc.GlobalPermissions = account.Permissions
+			continue
+		}
+		publicKey, err := publicKeyFromHex(account.PublicKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read the public key of account %s: %v", account.Address, err)
+		}
+		genesisDoc.Accounts = append(genesisDoc.Accounts, genesis.Account{
+			BasicAccount: genesis.BasicAccount{
+				Address:   account.Address,
+				PublicKey: publicKey,
+				Amount:    account.Balance,
+			},
+			Name:        account.Address.String(),
+			Permissions: account.Permissions,
+		})
+		if account.Code != "" {
+			missed = append(missed, fmt.Sprintf("the code and storage of contract %s cannot be held by a genesis",
+				account.Address))
+		}
+	}
+	for _, name := range state.Names {
+		missed = append(missed, fmt.Sprintf("name %s cannot be held by a genesis", name.Name))
+	}
+
+	for _, validator := range state.Validators {
+		if validator.Unbonding {
+			missed = append(missed, fmt.Sprintf("validator %s is unbonding", validator.Address))
+			continue
+		}
+		publicKey, err := publicKeyFromHex(validator.PublicKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read the public key of validator %s: %v", validator.Address, err)
+		}
+		account := genesis.BasicAccount{Address: validator.Address, PublicKey: publicKey, Amount: validator.Power}
+		genesisDoc.Validators = append(genesisDoc.Validators, genesis.Validator{
+			BasicAccount: account,
+			Name:         validator.Address.String(),
+			UnbondTo:     []genesis.BasicAccount{account},
+		})
+	}
+	if len(genesisDoc.Validators) == 0 {
+		missed = append(missed, "the dump holds no validators, add some to the genesis before starting a chain from it")
+	}
+	return genesisDoc, missed, nil
+}
+
+// replayState sends the transactions reproducing the accounts, permissions and names of a dump on
+// the chain from the source, which needs the funds and permissions to do so. Contracts are deployed
+// with their code and storage at new addresses. It returns what could not be reproduced
+func replayState(state *StateDump, source string, do *definitions.Do) []string {
+	var missed []string
+	miss := func(format string, args ...interface{}) {
+		missed = append(missed, fmt.Sprintf(format, args...))
+	}
+
+	for _, account := range state.Accounts {
+		address := account.Address.String()
+		if account.Address == permission.GlobalPermissionsAddress {
+			for _, perm := range permissionTxs(account.Permissions, "setGlobal", "") {
+				if _, err := PermissionJob(withSource(perm, source), do); err != nil {
+					miss("global permission %s: %v", perm.PermissionFlag, err)
+				}
+			}
+			continue
+		}
+
+		switch {
+		case account.Code != "":
+			created, err := replayContract(account, source, do)
+			if err != nil {
+				miss("contract %s: %v", address, err)
+				continue
+			}
+			miss("contract %s was deployed at %s", address, created)
+			address = created
+		case strings.EqualFold(address, source):
+			// the balance of the source is what it has on this chain
+		default:
+			send := &definitions.Send{Source: source, Destination: address, Amount: strconv.FormatUint(account.Balance, 10)}
+			if _, err := SendJob(send, do); err != nil {
+				miss("balance of account %s: %v", address, err)
+				continue
+			}
+		}
+
+		for _, perm := range permissionTxs(account.Permissions, "setBase", address) {
+			if _, err := PermissionJob(withSource(perm, source), do); err != nil {
+				miss("permission %s of account %s: %v", perm.PermissionFlag, address, err)
+			}
+		}
+		for _, role := range account.Permissions.Roles {
+			perm := &definitions.Permission{Action: "addRole", Target: address, Role: role}
+			if _, err := PermissionJob(withSource(perm, source), do); err != nil {
+				miss("role %s of account %s: %v", role, address, err)
+			}
+		}
+	}
+
+	for _, name := range state.Names {
+		if name.Expires <= state.BlockHeight {
+			miss("name %s had expired", name.Name)
+			continue
+		}
+		blocks := name.Expires - state.BlockHeight
+		if blocks < txs.MinNameRegistrationPeriod {
+			blocks = txs.MinNameRegistrationPeriod
+		}
+		amount := txs.NameCostPerBlock(txs.NameBaseCost(name.Name, name.Data)) * blocks
+		_, err := registerNameTx(&definitions.RegisterName{Source: source, Name: name.Name, Data: name.Data,
+			Amount: strconv.FormatUint(amount, 10)}, do)
+		if err != nil {
+			miss("name %s: %v", name.Name, err)
+		} else if !strings.EqualFold(name.Owner.String(), source) {
+			miss("name %s is owned by %s rather than %s", name.Name, source, name.Owner)
+		}
+	}
+
+	for _, validator := range state.Validators {
+		miss("validator %s cannot be bonded by replaying", validator.Address)
+	}
+	return missed
+}
+
+// replayContract deploys, with the balance of a dumped contract, code which sets its storage and
+// returns its code. It returns the address of the contract created
+func replayContract(account *DumpedAccount, source string, do *definitions.Do) (string, error) {
+	code, err := restorerCode(account)
+	if err != nil {
+		return "", err
+	}
+
+	// Don't use pubKey if account override
+	publicKey := do.PublicKey
+	if source != do.Package.Account {
+		publicKey = ""
+	}
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	keyClient := keys.NewKeyClient(do.Signer, loggers.NewNoopInfoTraceLogger())
+	tx, err := rpc.Call(nodeClient, keyClient, publicKey, source, "", strconv.FormatUint(account.Balance, 10), "",
+		do.DefaultGas, do.DefaultFee, code)
+	if err != nil {
+		return "", err
+	}
+	res, err := signAndBroadcast(do, tx)
+	if err != nil {
+		return "", err
+	}
+	if res.Exception != "" {
+		return "", fmt.Errorf("deploying the code failed: %s", res.Exception)
+	}
+	if res.Address == nil {
+		return "", fmt.Errorf("deploying the code did not create a contract")
+	}
+	return res.Address.String(), nil
+}
+
+// restorerCode is the hex of init code which stores each word of the storage of a contract and then
+// returns its code:
+//
+//	PUSH32 value PUSH32 key SSTORE ...
+//	PUSH2 size DUP1 PUSH2 offset PUSH1 0 CODECOPY PUSH1 0 RETURN code
+func restorerCode(account *DumpedAccount) (string, error) {
+	code, err := hex.DecodeString(account.Code)
+	if err != nil {
+		return "", fmt.Errorf("code is not hex encoded: %v", err)
+	}
+	if len(code) > 0xffff {
+		return "", fmt.Errorf("code of %d bytes is too long", len(code))
+	}
+	var init []byte
+	for _, item := range account.Storage {
+		key, err := storageWord(item.Key)
+		if err != nil {
+			return "", err
+		}
+		value, err := storageWord(item.Value)
+		if err != nil {
+			return "", err
+		}
+		init = append(init, 0x7f)
+		init = append(init, value...)
+		init = append(init, 0x7f)
+		init = append(init, key...)
+		init = append(init, 0x55)
+	}
+	// the length of the code copying the contract code after the init code
+	const copierLength = 13
+	offset := len(init) + copierLength
+	if offset > 0xffff {
+		return "", fmt.Errorf("storage of %d words is too large", len(account.Storage))
+	}
+	init = append(init, 0x61, byte(len(code)>>8), byte(len(code)), 0x80, 0x61, byte(offset>>8), byte(offset),
+		0x60, 0x00, 0x39, 0x60, 0x00, 0xf3)
+	return strings.ToUpper(hex.EncodeToString(append(init, code...))), nil
+}
+
+// storageWord reads a key or value of storage as the 32 byte word it is, left padded
+func storageWord(word string) ([]byte, error) {
+	b, err := hex.DecodeString(word)
+	if err != nil {
+		return nil, fmt.Errorf("storage %s is not hex encoded: %v", word, err)
+	}
+	if len(b) > 32 {
+		return nil, fmt.Errorf("storage %s is longer than a word", word)
+	}
+	return append(make([]byte, 32-len(b)), b...), nil
+}
+
+// permissionTxs are the permission jobs setting each base permission which is set explicitly,
+// rather than falling back to the global permissions
+func permissionTxs(perms ptypes.AccountPermissions, action, target string) []*definitions.Permission {
+	var jobs []*definitions.Permission
+	for i := uint(0); i < permission.NumPermissions; i++ {
+		flag := ptypes.PermFlag(1) << i
+		if perms.Base.SetBit&flag == 0 {
+			continue
+		}
+		jobs = append(jobs, &definitions.Permission{
+			Action:         action,
+			Target:         target,
+			PermissionFlag: permission.PermFlagToString(flag),
+			Value:          strconv.FormatBool(perms.Base.Perms&flag != 0),
+		})
+	}
+	return jobs
+}
+
+func withSource(perm *definitions.Permission, source string) *definitions.Permission {
+	perm.Source = source
+	return perm
+}
+
+// stateReadAttempts is how many times the state is read before giving up when blocks keep being
+// committed while it is read
+const stateReadAttempts = 5
+
+// readState reads every account, with the storage of contracts, and every name registry entry
+// from the chain, and optionally its validators. These take several requests, so the state is read
+// again when a block was committed in between, for the dump to be the state as of one block
+func readState(do *definitions.Do, withValidators bool) (*StateDump, error) {
+	for attempt := 1; ; attempt++ {
+		state, height, err := readStateOnce(do, withValidators)
+		if err != nil {
+			return nil, err
+		}
+		if height == state.BlockHeight {
+			return state, nil
+		}
+		if attempt == stateReadAttempts {
+			return nil, fmt.Errorf("the chain moved on from block %d to %d while its state was read, %d times over, "+
+				"dump it while no transactions are sent to it", state.BlockHeight, height, attempt)
+		}
+		log.WithFields(log.Fields{
+			"from": state.BlockHeight,
+			"to":   height,
+		}).Warn("Chain Moved On While Reading State, Reading Again")
+	}
+}
+
+// readStateOnce reads the state as readState does, along with the height of the chain when the last
+// of it was read
+func readStateOnce(do *definitions.Do, withValidators bool) (*StateDump, uint64, error) {
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	_, chainID, _, err := nodeClient.ChainId()
+	if err != nil {
+		return nil, 0, err
+	}
+	rpcClient := rpcclient.NewJSONRPCClient(do.ChainURL)
+
+	accounts := new(burrow_rpc.ResultListAccounts)
+	if _, err := rpcClient.Call(tm.ListAccounts, map[string]interface{}{}, accounts); err != nil {
+		return nil, 0, fmt.Errorf("could not list the accounts of the chain: %v", err)
+	}
+	state := &StateDump{
+		Version:     stateDumpVersion,
+		ChainID:     chainID,
+		BlockHeight: accounts.BlockHeight,
+	}
+	for _, account := range accounts.Accounts {
+		var storage *burrow_rpc.ResultDumpStorage
+		if len(account.Code) > 0 {
+			if storage, err = nodeClient.DumpStorage(account.Address); err != nil {
+				return nil, 0, err
+			}
+		}
+		state.Accounts = append(state.Accounts, dumpAccount(account, storage))
+	}
+
+	names := new(burrow_rpc.ResultListNames)
+	if _, err := rpcClient.Call(tm.ListNames, map[string]interface{}{}, names); err != nil {
+		return nil, 0, fmt.Errorf("could not list the names of the chain: %v", err)
+	}
+	height := names.BlockHeight
+	for _, entry := range names.Names {
+		state.Names = append(state.Names, &DumpedName{
+			Name:    entry.Name,
+			Owner:   entry.Owner,
+			Data:    entry.Data,
+			Expires: entry.Expires,
+		})
+	}
+
+	if withValidators {
+		var bonded, unbonding []acm.Validator
+		height, bonded, unbonding, err = nodeClient.ListValidators()
+		if err != nil {
+			return nil, 0, err
+		}
+		state.Validators = append(dumpValidators(bonded, false), dumpValidators(unbonding, true)...)
+	}
+	return state, height, nil
+}
+
+func dumpAccount(account *acm.ConcreteAccount, storage *burrow_rpc.ResultDumpStorage) *DumpedAccount {
+	dumped := &DumpedAccount{
+		Address:     account.Address,
+		PublicKey:   publicKeyHex(account.PublicKey),
+		Sequence:    account.Sequence,
+		Balance:     account.Balance,
+		Code:        strings.ToUpper(hex.EncodeToString(account.Code)),
+		Permissions: account.Permissions,
+	}
+	if storage != nil {
+		for _, item := range storage.StorageItems {
+			dumped.Storage = append(dumped.Storage, &DumpedStorage{
+				Key:   strings.ToUpper(hex.EncodeToString(item.Key)),
+				Value: strings.ToUpper(hex.EncodeToString(item.Value)),
+			})
+		}
+	}
+	return dumped
+}
+
+func dumpValidators(validators []acm.Validator, unbonding bool) []*DumpedValidator {
+	var dumped []*DumpedValidator
+	for _, validator := range validators {
+		dumped = append(dumped, &DumpedValidator{
+			Address:   validator.Address(),
+			PublicKey: publicKeyHex(validator.PublicKey()),
+			Power:     validator.Power(),
+			Unbonding: unbonding,
+		})
+	}
+	return dumped
+}
+
+func publicKeyFromHex(publicKey string) (acm.PublicKey, error) {
+	if publicKey == "" {
+		return acm.PublicKey{}, nil
+	}
+	b, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return acm.PublicKey{}, err
+	}
+	return acm.PublicKeyFromBytes(b)
+}
+
+func publicKeyHex(publicKey acm.PublicKey) string {
+	if publicKey.PubKey.Empty() {
+		return ""
+	}
+	return strings.ToUpper(hex.EncodeToString(publicKey.RawBytes()))
+}
 
-	return result, nil
+func (state *StateDump) write(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0775); err != nil {
+		return err
+	}
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, contents, 0664)
 }
@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/genesis"
+	"github.com/hyperledger/burrow/permission"
+	ptypes "github.com/hyperledger/burrow/permission/types"
+	burrow_rpc "github.com/hyperledger/burrow/rpc"
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+func TestDumpAccount(t *testing.T) {
+	publicKey, err := acm.PublicKeyFromBytes(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := &acm.ConcreteAccount{
+		Address:     publicKey.Address(),
+		PublicKey:   publicKey,
+		Sequence:    3,
+		Balance:     1234,
+		Code:        acm.Bytecode{0x60, 0x60},
+		Permissions: ptypes.AccountPermissions{Base: ptypes.BasePermissions{Perms: 7, SetBit: 7}, Roles: []string{"root"}},
+	}
+	storage := &burrow_rpc.ResultDumpStorage{StorageItems: []burrow_rpc.StorageItem{{Key: []byte{1}, Value: []byte{0xab}}}}
+
+	dumped := dumpAccount(account, storage)
+	if dumped.Address != account.Address || dumped.Sequence != 3 || dumped.Balance != 1234 || dumped.Code != "6060" ||
+		len(dumped.PublicKey) != 64 || !reflect.DeepEqual(dumped.Permissions, account.Permissions) {
+		t.Errorf("unexpected dumped account %+v", dumped)
+	}
+	if len(dumped.Storage) != 1 || dumped.Storage[0].Key != "01" || dumped.Storage[0].Value != "AB" {
+		t.Errorf("unexpected dumped storage %+v", dumped.Storage)
+	}
+
+	if dumped := dumpAccount(&acm.ConcreteAccount{Balance: 5}, nil); dumped.PublicKey != "" || dumped.Code != "" ||
+		dumped.Storage != nil {
+		t.Errorf("expected an account which has not sent a transaction to have no public key, got %+v", dumped)
+	}
+}
+
+func TestWriteStateDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state := &StateDump{
+		Version:     stateDumpVersion,
+		ChainID:     "marmots",
+		BlockHeight: 12,
+		Accounts: []*DumpedAccount{{Address: acm.Address{1}, Balance: 5, Code: "6060",
+			Storage:     []*DumpedStorage{{Key: "01", Value: "AB"}},
+			Permissions: ptypes.AccountPermissions{Base: ptypes.BasePermissions{Perms: 1, SetBit: 1}}}},
+		Names:      []*DumpedName{{Name: "dumped", Owner: acm.Address{1}, Data: "restore me", Expires: 100}},
+		Validators: []*DumpedValidator{{Address: acm.Address{2}, PublicKey: "AB", Power: 10}},
+	}
+	file := filepath.Join(dir, "nested", "dump.json")
+	if err := state.write(file); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := new(StateDump)
+	if err := json.Unmarshal(contents, read); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state, read) {
+		t.Errorf("expected the dump to be written as json\n%+v\n%+v", state, read)
+	}
+	if read, err = readStateDump(file); err != nil || !reflect.DeepEqual(state, read) {
+		t.Errorf("expected the dump to be read back as written: %v", err)
+	}
+
+	for _, version := range []int{0, stateDumpVersion + 1} {
+		state.Version = version
+		if err := state.write(file); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readStateDump(file); err == nil {
+			t.Errorf("expected an error reading a dump of version %d", version)
+		}
+	}
+}
+
+func TestStateGenesis(t *testing.T) {
+	publicKey := strings.Repeat("CD", 32)
+	global := ptypes.AccountPermissions{Base: ptypes.BasePermissions{Perms: permission.Send, SetBit: permission.AllPermFlags}}
+	perms := ptypes.AccountPermissions{Base: ptypes.BasePermissions{Perms: permission.Call, SetBit: permission.Call},
+		Roles: []string{"marmot"}}
+	state := &StateDump{
+		Version:     stateDumpVersion,
+		ChainID:     "marmots",
+		BlockHeight: 12,
+		Accounts: []*DumpedAccount{
+			{Address: permission.GlobalPermissionsAddress, Balance: 1337, Permissions: global},
+			{Address: acm.Address{1}, PublicKey: publicKey, Sequence: 4, Balance: 5, Permissions: perms},
+			{Address: acm.Address{2}, Balance: 7, Code: "6060"},
+		},
+		Names: []*DumpedName{{Name: "dumped", Owner: acm.Address{1}, Data: "restore me", Expires: 100}},
+		Validators: []*DumpedValidator{{Address: acm.Address{3}, PublicKey: publicKey, Power: 10},
+			{Address: acm.Address{4}, PublicKey: publicKey, Power: 1, Unbonding: true}},
+	}
+
+	genesisDoc, missed, err := stateGenesis(state, "restored", time.Unix(1500000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genesisDoc.ChainName != "restored" || !reflect.DeepEqual(genesisDoc.GlobalPermissions, global) {
+		t.Errorf("unexpected genesis %+v", genesisDoc)
+	}
+	if len(genesisDoc.Accounts) != 2 || genesisDoc.Accounts[0].Amount != 5 ||
+		!reflect.DeepEqual(genesisDoc.Accounts[0].Permissions, perms) ||
+		genesisDoc.Accounts[0].PublicKey.PubKey.Empty() ||
+		!genesisDoc.Accounts[1].PublicKey.PubKey.Empty() {
+		t.Errorf("unexpected genesis accounts %+v", genesisDoc.Accounts)
+	}
+	if len(genesisDoc.Validators) != 1 || genesisDoc.Validators[0].Amount != 10 ||
+		genesisDoc.Validators[0].UnbondTo[0].Address != (acm.Address{3}) {
+		t.Errorf("unexpected genesis validators %+v", genesisDoc.Validators)
+	}
+	if len(missed) != 3 || !strings.Contains(missed[0], "contract "+acm.Address{2}.String()) ||
+		!strings.Contains(missed[1], "name dumped") || !strings.Contains(missed[2], "unbonding") {
+		t.Errorf("unexpected things not restored %v", missed)
+	}
+
+	contents, err := genesisDoc.JSONBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := genesis.GenesisDocFromJSON(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Accounts[0].PublicKey.Address() != genesisDoc.Accounts[0].PublicKey.Address() {
+		t.Errorf("expected the genesis to be read back as written")
+	}
+
+	state.Validators = nil
+	if _, missed, _ = stateGenesis(state, "restored", time.Now()); !strings.Contains(missed[len(missed)-1], "no validators") {
+		t.Errorf("expected a genesis without validators to be reported, got %v", missed)
+	}
+	state.Accounts[1].PublicKey = "zz"
+	if _, _, err = stateGenesis(state, "restored", time.Now()); err == nil {
+		t.Errorf("expected an error restoring an account with an invalid public key")
+	}
+}
+
+func TestRestorerCode(t *testing.T) {
+	code, err := restorerCode(&DumpedAccount{Code: "6060", Storage: []*DumpedStorage{{Key: "01", Value: "AB"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	word := func(b string) string { return strings.Repeat("00", 31) + b }
+	// 67 bytes storing the word, then 13 bytes copying the code from offset 80 and returning it
+	expected := "7F" + word("AB") + "7F" + word("01") + "55" + "610002" + "80" + "610050" + "6000" + "39" + "6000" + "F3" +
+		"6060"
+	if code != expected {
+		t.Errorf("expected the restorer code\n%s, got\n%s", expected, code)
+	}
+
+	code, err = restorerCode(&DumpedAccount{Code: "6060"})
+	if err != nil || code != "610002"+"80"+"61000D"+"6000"+"39"+"6000"+"F3"+"6060" {
+		t.Errorf("unexpected restorer code of a contract without storage %s: %v", code, err)
+	}
+
+	for _, account := range []*DumpedAccount{
+		{Code: "zz"},
+		{Code: "6060", Storage: []*DumpedStorage{{Key: strings.Repeat("01", 33), Value: "01"}}},
+		{Code: "6060", Storage: []*DumpedStorage{{Key: "01", Value: "z"}}},
+	} {
+		if _, err := restorerCode(account); err == nil {
+			t.Errorf("expected an error making the restorer code of %+v", account)
+		}
+	}
+}
+
+func TestPermissionTxs(t *testing.T) {
+	perms := ptypes.AccountPermissions{Base: ptypes.BasePermissions{Perms: permission.Call,
+		SetBit: permission.Send | permission.Call}}
+	expected := []*definitions.Permission{
+		{Action: "setBase", Target: "AB", PermissionFlag: "send", Value: "false"},
+		{Action: "setBase", Target: "AB", PermissionFlag: "call", Value: "true"},
+	}
+	if jobs := permissionTxs(perms, "setBase", "AB"); !reflect.DeepEqual(jobs, expected) {
+		t.Errorf("expected the permission jobs %v, got %v", expected, jobs)
+	}
+	if jobs := permissionTxs(ptypes.AccountPermissions{}, "setBase", "AB"); len(jobs) != 0 {
+		t.Errorf("expected no permission jobs for permissions falling back to the global ones, got %v", jobs)
+	}
+}
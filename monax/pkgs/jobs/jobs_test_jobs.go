@@ -3,15 +3,23 @@ package jobs
 import (
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	acm "github.com/hyperledger/burrow/account"
 	"github.com/hyperledger/burrow/client"
 	"github.com/hyperledger/burrow/logging/loggers"
+	burrow_rpc "github.com/hyperledger/burrow/rpc"
+	tm_client "github.com/hyperledger/burrow/rpc/tm/client"
 	"github.com/monax/bosmarmot/monax/definitions"
 	"github.com/monax/bosmarmot/monax/log"
 	"github.com/monax/bosmarmot/monax/pkgs/abi"
 	"github.com/monax/bosmarmot/monax/util"
+	rpcclient "github.com/tendermint/tendermint/rpc/lib/client"
 )
 
 func QueryContractJob(query *definitions.QueryContract, do *definitions.Do) (string, []*definitions.Variable, error) {
@@ -19,10 +27,22 @@ func QueryContractJob(query *definitions.QueryContract, do *definitions.Do) (str
 	query.Source, _ = util.PreProcess(query.Source, do)
 	query.Destination, _ = util.PreProcess(query.Destination, do)
 	query.ABI, _ = util.PreProcess(query.ABI, do)
+	query.RawData, _ = util.PreProcess(query.RawData, do)
+	query.BytesEncoding, _ = util.PreProcess(query.BytesEncoding, do)
+	query.BytesEncoding = useDefault(query.BytesEncoding, do.BytesEncoding)
+	if err := abi.CheckBytesEncoding(query.BytesEncoding); err != nil {
+		return "", nil, err
+	}
 
+	var data string
 	var queryDataArray []string
 	var err error
-	query.Function, queryDataArray, err = util.PreProcessInputData(query.Function, query.Data, do, false)
+	if query.RawData != "" {
+		data, err = rawCallData(query.RawData, query.Function, query.Data)
+	} else if query.Function, err = canonicalFunction(query.Function); err == nil {
+		query.Function, queryDataArray, err = abi.PreProcessInputData(useDefault(query.ABI, query.Destination),
+			query.Function, query.Data, do, false)
+	}
 	if err != nil {
 		return "", nil, err
 	}
@@ -41,19 +61,19 @@ func QueryContractJob(query *definitions.QueryContract, do *definitions.Do) (str
 	}
 
 	// Get the packed data from the ABI functions
-	var data string
-	var packedBytes []byte
-	if query.ABI == "" {
-		packedBytes, err = abi.ReadAbiFormulateCall(query.Destination, query.Function, queryDataArray, do)
-		data = hex.EncodeToString(packedBytes)
-	} else {
-		packedBytes, err = abi.ReadAbiFormulateCall(query.ABI, query.Function, queryDataArray, do)
+	if query.RawData == "" {
+		var packedBytes []byte
+		if query.ABI == "" {
+			packedBytes, err = abi.ReadAbiFormulateCall(query.Destination, query.Function, queryDataArray, do)
+		} else {
+			packedBytes, err = abi.ReadAbiFormulateCall(query.ABI, query.Function, queryDataArray, do)
+		}
+		if err != nil {
+			var str, err = util.ABIErrorHandler(do, err, nil, query)
+			return str, nil, err
+		}
 		data = hex.EncodeToString(packedBytes)
 	}
-	if err != nil {
-		var str, err = util.ABIErrorHandler(do, err, nil, query)
-		return str, nil, err
-	}
 	dataBytes, err := hex.DecodeString(data)
 	if err != nil {
 		return "", nil, err
@@ -66,14 +86,20 @@ func QueryContractJob(query *definitions.QueryContract, do *definitions.Do) (str
 		return "", nil, err
 	}
 
+	if query.RawData != "" {
+		rawResult := fmt.Sprintf("%X", result)
+		log.WithField("=>", rawResult).Warn("Raw Return Value")
+		return rawResult, nil, nil
+	}
+
 	// Formally process the return
 	log.WithField("res", result).Debug("Decoding Raw Result")
 	if query.ABI == "" {
 		log.WithField("abi", query.Destination).Debug()
-		query.Variables, err = abi.ReadAndDecodeContractReturn(query.Destination, query.Function, result, do)
+		query.Variables, err = abi.ReadAndDecodeContractReturn(query.Destination, query.Function, result, query.BytesEncoding, do)
 	} else {
 		log.WithField("abi", query.ABI).Debug()
-		query.Variables, err = abi.ReadAndDecodeContractReturn(query.ABI, query.Function, result, do)
+		query.Variables, err = abi.ReadAndDecodeContractReturn(query.ABI, query.Function, result, query.BytesEncoding, do)
 	}
 	if err != nil {
 		return "", nil, err
@@ -156,12 +182,156 @@ func QueryValsJob(query *definitions.QueryVals, do *definitions.Do) (string, err
 	return result, nil
 }
 
+func QueryStorageJob(query *definitions.QueryStorage, do *definitions.Do) (string, error) {
+	// Preprocess variables
+	query.Account, _ = util.PreProcess(query.Account, do)
+	query.Slot, _ = util.PreProcess(query.Slot, do)
+	query.Key, _ = util.PreProcess(query.Key, do)
+	query.KeyType, _ = util.PreProcess(query.KeyType, do)
+	query.Type, _ = util.PreProcess(query.Type, do)
+	query.BytesEncoding, _ = util.PreProcess(query.BytesEncoding, do)
+	query.BytesEncoding = useDefault(query.BytesEncoding, do.BytesEncoding)
+	if err := abi.CheckBytesEncoding(query.BytesEncoding); err != nil {
+		return "", err
+	}
+	if query.Key != "" {
+		query.KeyType = useDefault(query.KeyType, "uint256")
+	}
+
+	key, err := abi.StorageKey(query.Slot, query.KeyType, query.Key)
+	if err != nil {
+		return "", err
+	}
+	log.WithFields(log.Fields{
+		"account": query.Account,
+		"slot":    query.Slot,
+		"key":     fmt.Sprintf("%X", key),
+	}).Info("Querying Storage")
+	word, err := util.StorageInfo(query.Account, key, do)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%X", word)
+	if query.Type != "" {
+		if result, err = abi.DecodeStorage(query.Type, word, query.Offset, query.BytesEncoding); err != nil {
+			return "", err
+		}
+	}
+	log.WithField("=>", result).Warn("Return Value")
+	return result, nil
+}
+
+func QueryTxJob(query *definitions.QueryTx, do *definitions.Do) (string, []*definitions.Variable, error) {
+	// Preprocess variables
+	query.Hash, _ = util.PreProcess(query.Hash, do)
+	query.ABI, _ = util.PreProcess(query.ABI, do)
+	query.BytesEncoding, _ = util.PreProcess(query.BytesEncoding, do)
+	query.BytesEncoding = useDefault(query.BytesEncoding, do.BytesEncoding)
+	if err := abi.CheckBytesEncoding(query.BytesEncoding); err != nil {
+		return "", nil, err
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(query.Hash, "0x"))
+	if err != nil {
+		return "", nil, fmt.Errorf("transaction hash %s is not hex encoded: %v", query.Hash, err)
+	}
+
+	log.WithField("hash", fmt.Sprintf("%X", hash)).Info("Querying Transaction")
+	res, ok := receipts[fmt.Sprintf("%X", hash)]
+	if !ok {
+		return "", nil, fmt.Errorf("transaction %X was not sent earlier in this run of the package, the node keeps no "+
+			"receipts so its status, gas used, return data, exception and events cannot be looked up", hash)
+	}
+	abiLocation := useDefault(query.ABI, res.Contract.String())
+	abiData, err := util.ReadAbi(do, abiLocation)
+	if err != nil {
+		log.WithField("=>", err).Debug("No abi to decode events with")
+		abiData = ""
+	}
+	vars := receiptVariables(res, abiData, query.BytesEncoding, do)
+	for _, v := range vars {
+		log.WithField("=>", fmt.Sprintf("%s,%s", v.Name, v.Value)).Info("Transaction")
+	}
+	return vars[0].Value, vars, nil
+}
+
+// the status of a transaction the package sent which executed, or threw
+const (
+	txOk       = "ok"
+	txReverted = "reverted"
+)
+
+// receiptVariables turns the result of a transaction into the variables of a query-tx job, with
+// the status first. The events are decoded as for the call job when there is an abi
+func receiptVariables(res *txResult, abiData, encoding string, do *definitions.Do) []*definitions.Variable {
+	status := txOk
+	if res.Exception != "" {
+		status = txReverted
+	}
+	vars := []*definitions.Variable{
+		{Name: "status", Value: status},
+		{Name: "height", Value: strconv.FormatInt(res.BlockHeight, 10)},
+		{Name: "gas.used", Value: strconv.FormatUint(res.GasUsed, 10)},
+		{Name: "return", Value: fmt.Sprintf("%X", res.Return)},
+		{Name: "exception", Value: res.Exception},
+		{Name: "contract", Value: res.Contract.String()},
+	}
+	if abiData != "" {
+		vars = append(vars, eventVariables(abiData, res.Logs, encoding, do)...)
+	}
+	return vars
+}
+
+func QueryBlockJob(query *definitions.QueryBlock, do *definitions.Do) (string, []*definitions.Variable, error) {
+	// Preprocess variables
+	query.Height, _ = util.PreProcess(query.Height, do)
+
+	log.WithField("height", query.Height).Info("Querying Block")
+	block, err := getBlock(query.Height, do)
+	if err != nil {
+		return "", nil, err
+	}
+	vars := blockVariables(block)
+	for _, v := range vars {
+		log.WithField("=>", fmt.Sprintf("%s,%s", v.Name, v.Value)).Info("Block")
+	}
+	return vars[0].Value, vars, nil
+}
+
+// blockVariables are the variables of a query-block job, with the hash of the block first. This
+// version of tendermint does not record the proposer of a block, so it is not given
+func blockVariables(block *burrow_rpc.ResultGetBlock) []*definitions.Variable {
+	header := block.BlockMeta.Header
+	return []*definitions.Variable{
+		{Name: "hash", Value: fmt.Sprintf("%X", []byte(block.BlockMeta.BlockID.Hash))},
+		{Name: "height", Value: strconv.FormatInt(header.Height, 10)},
+		{Name: "time", Value: header.Time.UTC().Format(time.RFC3339)},
+		{Name: "txs", Value: strconv.FormatInt(header.NumTxs, 10)},
+	}
+}
+
+// getBlock fetches the block at the height, which the node gives as empty above its latest block
+func getBlock(height string, do *definitions.Do) (*burrow_rpc.ResultGetBlock, error) {
+	h, err := strconv.Atoi(height)
+	if err != nil || h <= 0 {
+		return nil, fmt.Errorf("block height %s is not a positive integer", height)
+	}
+	block, err := tm_client.GetBlock(rpcclient.NewJSONRPCClient(do.ChainURL), h)
+	if err != nil {
+		return nil, err
+	}
+	if block.Block == nil || block.BlockMeta == nil {
+		return nil, fmt.Errorf("there is no block at height %d", h)
+	}
+	return block, nil
+}
+
 func AssertJob(assertion *definitions.Assert, do *definitions.Do) (string, error) {
-	var result string
 	// Preprocess variables
 	assertion.Key, _ = util.PreProcess(assertion.Key, do)
 	assertion.Relation, _ = util.PreProcess(assertion.Relation, do)
 	assertion.Value, _ = util.PreProcess(assertion.Value, do)
+	assertion.Tolerance, _ = util.PreProcess(assertion.Tolerance, do)
 
 	// Switch on relation
 	log.WithFields(log.Fields{
@@ -172,77 +342,186 @@ func AssertJob(assertion *definitions.Assert, do *definitions.Do) (string, error
 
 	switch assertion.Relation {
 	case "==", "eq":
-		/*log.Debug("Compare", strings.Compare(assertion.Key, assertion.Value))
-		log.Debug("UTF8?: ", utf8.ValidString(assertion.Key))
-		log.Debug("UTF8?: ", utf8.ValidString(assertion.Value))
-		log.Debug("UTF8?: ", utf8.RuneCountInString(assertion.Key))
-		log.Debug("UTF8?: ", utf8.RuneCountInString(assertion.Value))*/
-		if assertion.Key == assertion.Value {
-			return assertPass("==", assertion.Key, assertion.Value)
-		} else {
-			return assertFail("==", assertion.Key, assertion.Value)
-		}
+		return assertResult(equivalent(assertion.Key, assertion.Value), "==", assertion.Key, assertion.Value)
 	case "!=", "ne":
-		if assertion.Key != assertion.Value {
-			return assertPass("!=", assertion.Key, assertion.Value)
-		} else {
-			return assertFail("!=", assertion.Key, assertion.Value)
-		}
+		return assertResult(!equivalent(assertion.Key, assertion.Value), "!=", assertion.Key, assertion.Value)
 	case ">", "gt":
 		k, v, err := bulkConvert(assertion.Key, assertion.Value)
 		if err != nil {
-			return convFail()
-		}
-		if k > v {
-			return assertPass(">", assertion.Key, assertion.Value)
-		} else {
-			return assertFail(">", assertion.Key, assertion.Value)
+			return convFail(err)
 		}
+		return assertResult(k.Cmp(v) > 0, ">", assertion.Key, assertion.Value)
 	case ">=", "ge":
 		k, v, err := bulkConvert(assertion.Key, assertion.Value)
 		if err != nil {
-			return convFail()
-		}
-		if k >= v {
-			return assertPass(">=", assertion.Key, assertion.Value)
-		} else {
-			return assertFail(">=", assertion.Key, assertion.Value)
+			return convFail(err)
 		}
+		return assertResult(k.Cmp(v) >= 0, ">=", assertion.Key, assertion.Value)
 	case "<", "lt":
 		k, v, err := bulkConvert(assertion.Key, assertion.Value)
 		if err != nil {
-			return convFail()
-		}
-		if k < v {
-			return assertPass("<", assertion.Key, assertion.Value)
-		} else {
-			return assertFail("<", assertion.Key, assertion.Value)
+			return convFail(err)
 		}
+		return assertResult(k.Cmp(v) < 0, "<", assertion.Key, assertion.Value)
 	case "<=", "le":
 		k, v, err := bulkConvert(assertion.Key, assertion.Value)
 		if err != nil {
-			return convFail()
+			return convFail(err)
 		}
-		if k <= v {
-			return assertPass("<=", assertion.Key, assertion.Value)
-		} else {
-			return assertFail("<=", assertion.Key, assertion.Value)
+		return assertResult(k.Cmp(v) <= 0, "<=", assertion.Key, assertion.Value)
+	case "matches":
+		re, err := regexp.Compile(assertion.Value)
+		if err != nil {
+			return "", fmt.Errorf("The value of your matches assertion is not a valid regular expression: %v", err)
+		}
+		return assertResult(re.MatchString(assertion.Key), "matches", assertion.Key, assertion.Value)
+	case "contains":
+		return assertResult(contains(assertion.Key, assertion.Value), "contains", assertion.Key, assertion.Value)
+	case "not-contains":
+		return assertResult(!contains(assertion.Key, assertion.Value), "not-contains", assertion.Key, assertion.Value)
+	case "in":
+		return assertResult(listContains(splitList(assertion.Value), assertion.Key), "in", assertion.Key, assertion.Value)
+	case "approx":
+		k, v, err := bulkConvert(assertion.Key, assertion.Value)
+		if err != nil {
+			return convFail(err)
 		}
+		ok, err := approximately(k, v, assertion.Tolerance)
+		if err != nil {
+			return "", err
+		}
+		return assertResult(ok, fmt.Sprintf("approx (tolerance %s)", assertion.Tolerance), assertion.Key, assertion.Value)
+	case "len-eq":
+		v, err := util.ParseInteger(assertion.Value, nil)
+		if err != nil {
+			return "", fmt.Errorf("The value of your len-eq assertion must be an integer length, got \"%s\"", assertion.Value)
+		}
+		keyLength := big.NewInt(int64(length(assertion.Key)))
+		return assertResult(keyLength.Cmp(v) == 0, fmt.Sprintf("len-eq (length %s)", keyLength), assertion.Key, assertion.Value)
+	case "set-eq":
+		return assertResult(setEqual(splitList(assertion.Key), splitList(assertion.Value)), "set-eq", assertion.Key, assertion.Value)
+	case "emitted":
+		return assertResult(listContains(splitList(assertion.Key), assertion.Value), "emitted", assertion.Key, assertion.Value)
+	case "not-emitted":
+		return assertResult(!listContains(splitList(assertion.Key), assertion.Value), "not-emitted", assertion.Key, assertion.Value)
+	case "empty":
+		return assertResult(length(assertion.Key) == 0, "empty", assertion.Key, "")
+	case "not-empty":
+		return assertResult(length(assertion.Key) != 0, "not-empty", assertion.Key, "")
 	default:
 		return "", fmt.Errorf("Error: Bad assert relation: \"%s\" is not a valid relation. See documentation for more information.", assertion.Relation)
 	}
+}
 
-	return result, nil
+// isList reports whether str is an array in the "[a,b,c]" form that contract returns
+// and set jobs use
+func isList(str string) bool {
+	str = strings.TrimSpace(str)
+	return strings.HasPrefix(str, "[") && strings.HasSuffix(str, "]")
 }
 
-func bulkConvert(key, value string) (int, int, error) {
-	k, err := strconv.Atoi(key)
+// splitList breaks a "[a,b,c]" or "a,b,c" string into its trimmed elements
+func splitList(str string) []string {
+	str = strings.TrimSpace(str)
+	if isList(str) {
+		str = str[1 : len(str)-1]
+	}
+	if strings.TrimSpace(str) == "" {
+		return []string{}
+	}
+	elems := strings.Split(str, ",")
+	for i, elem := range elems {
+		elems[i] = strings.TrimSpace(elem)
+	}
+	return elems
+}
+
+// listContains reports whether elem is in list, comparing numerically where both are numbers
+func listContains(list []string, elem string) bool {
+	for _, item := range list {
+		if equivalent(item, elem) {
+			return true
+		}
+	}
+	return false
+}
+
+// equivalent compares numbers as numbers, so that "0010" equals "10" and "0xff" equals "255", and
+// anything else as strings
+func equivalent(a, b string) bool {
+	if x, y, err := bulkConvert(a, b); err == nil {
+		return x.Cmp(y) == 0
+	}
+	return a == b
+}
+
+// contains checks array membership when key is an array, otherwise it checks for a substring
+func contains(key, value string) bool {
+	if isList(key) {
+		return listContains(splitList(key), value)
+	}
+	return strings.Contains(key, value)
+}
+
+// length is the number of elements of an array or the number of characters of a string
+func length(str string) int {
+	if isList(str) {
+		return len(splitList(str))
+	}
+	return utf8.RuneCountInString(str)
+}
+
+// setEqual compares two arrays as multisets, ignoring the order of their elements
+func setEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	matched := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for j, y := range b {
+			if !matched[j] && equivalent(x, y) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// approximately checks that |key - value| <= tolerance. The tolerance is absolute unless it
+// ends in a % in which case it is taken relative to value
+func approximately(key, value *big.Rat, tolerance string) (bool, error) {
+	if tolerance == "" {
+		return false, fmt.Errorf("The approx relation requires a tolerance, e.g. tolerance: 0.01 or tolerance: 1%%")
+	}
+	relative := strings.HasSuffix(tolerance, "%")
+	tol, err := util.ParseNumber(strings.TrimSuffix(tolerance, "%"), nil)
 	if err != nil {
-		return 0, 0, err
+		return false, fmt.Errorf("Could not read the tolerance of your approx assertion: %v", err)
+	}
+	if relative {
+		tol.Mul(tol, new(big.Rat).Abs(value))
+		tol.Quo(tol, big.NewRat(100, 1))
 	}
-	v, err := strconv.Atoi(value)
+	diff := new(big.Rat).Sub(key, value)
+	return diff.Abs(diff).Cmp(tol.Abs(tol)) <= 0, nil
+}
+
+// bulkConvert reads the key and value as numbers, as util.ParseNumber reads them, so integers beyond
+// 64 bits and decimals with a scale are compared exactly
+func bulkConvert(key, value string) (*big.Rat, *big.Rat, error) {
+	k, err := util.ParseNumber(key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := util.ParseNumber(value, nil)
 	if err != nil {
-		return 0, 0, err
+		return nil, nil, err
 	}
 	return k, v, nil
 }
@@ -254,9 +533,16 @@ func assertPass(typ, key, val string) (string, error) {
 
 func assertFail(typ, key, val string) (string, error) {
 	log.WithField("=>", fmt.Sprintf("%s %s %s", key, typ, val)).Warn("Assertion Failed")
-	return "failed", fmt.Errorf("assertion failed")
+	return "failed", fmt.Errorf("assertion failed: key \"%s\" %s value \"%s\"", key, typ, val)
+}
+
+func assertResult(ok bool, typ, key, val string) (string, error) {
+	if ok {
+		return assertPass(typ, key, val)
+	}
+	return assertFail(typ, key, val)
 }
 
-func convFail() (string, error) {
-	return "", fmt.Errorf("The Key of your assertion cannot be converted into an integer.\nFor string conversions please use the equal or not equal relations.")
+func convFail(err error) (string, error) {
+	return "", fmt.Errorf("The key and value of your assertion cannot be converted into numbers: %v\nNumbers may be decimal (optionally with a fractional part or an exponent, as in 1.5e3, and with underscores between digits) or 0x prefixed hexadecimal.\nFor string comparisons please use the equal or not equal relations.", err)
 }
@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/binary"
+	"github.com/hyperledger/burrow/client/rpc"
+	evm_events "github.com/hyperledger/burrow/execution/evm/events"
+	burrow_rpc "github.com/hyperledger/burrow/rpc"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/pkgs/abi"
+	tm_types "github.com/tendermint/tendermint/types"
+)
+
+func TestAssertJob(t *testing.T) {
+	tests := []struct {
+		key      string
+		relation string
+		value    string
+		pass     bool
+	}{
+		{"marmot", "eq", "marmot", true},
+		{"marmot", "ne", "marmots", true},
+		{"0010", "eq", "10", true},
+		{"0010", "ne", "10", false},
+		{"0xff", "eq", "0xFF", true},
+		{"0xff", "eq", "255", true},
+		{"0xff", "ne", "255", false},
+		{"0x00", "eq", "0x0000", true},
+		{"0x10", "eq", "16", true},
+		{"0x10", "ne", "17", true},
+		{"1.50", "eq", "1.5", true},
+		{"1_000", "eq", "1000", true},
+		{"1e3", "eq", "0x3e8", true},
+		{"25e-3", "lt", "0.03", true},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "gt", "9223372036854775807", true},
+		{"9223372036854775808", "lt", "9223372036854775807", false},
+		{"-0x10", "lt", "-15", true},
+		{"0x10", "ge", "16", true},
+		{"2.25", "le", "2.3", true},
+		{"2.25", ">", "2.3", false},
+		{"0xdeadbeef", "matches", "^0x[0-9a-f]+$", true},
+		{"marmot", "matches", "^[0-9]+$", false},
+		{"[1,2,3]", "contains", "2", true},
+		{"[1,2,3]", "contains", "4", false},
+		{"marmots in the den", "contains", "den", true},
+		{"[1,2,3]", "not-contains", "4", true},
+		{"marmot", "not-contains", "mar", false},
+		{"2", "in", "[1,2,3]", true},
+		{"b", "in", "a, b, c", true},
+		{"d", "in", "a,b,c", false},
+		{"1000", "len-eq", "4", true},
+		{"[a,b,c]", "len-eq", "3", true},
+		{"[]", "len-eq", "0", true},
+		{"[a,b]", "len-eq", "3", false},
+		{"[3,1,2]", "set-eq", "[1,2,3]", true},
+		{"[1,1,2]", "set-eq", "[1,2,2]", false},
+		{"[0x01,02]", "set-eq", "[2,0x0001]", true},
+		{"", "empty", "", true},
+		{"[]", "empty", "", true},
+		{"marmot", "empty", "", false},
+		{"[1]", "not-empty", "", true},
+		{"[Transfer,Approval]", "emitted", "Approval", true},
+		{"[]", "emitted", "Transfer", false},
+		{"[Transfer]", "not-emitted", "Approval", true},
+	}
+	for _, tt := range tests {
+		assertion := &definitions.Assert{Key: tt.key, Relation: tt.relation, Value: tt.value}
+		result, err := AssertJob(assertion, definitions.NowDo())
+		if tt.pass && (err != nil || result != "passed") {
+			t.Errorf("expected %s %s %s to pass, got %s: %v", tt.key, tt.relation, tt.value, result, err)
+		}
+		if !tt.pass && err == nil {
+			t.Errorf("expected %s %s %s to fail, got %s", tt.key, tt.relation, tt.value, result)
+		}
+	}
+}
+
+func TestAssertJobApprox(t *testing.T) {
+	tests := []struct {
+		key       string
+		value     string
+		tolerance string
+		pass      bool
+	}{
+		{"100", "101", "1", true},
+		{"100", "102", "1", false},
+		{"99", "100", "1%", true},
+		{"98", "100", "1%", false},
+		{"1000000000000000000000", "1000000000000000000001", "0.5", false},
+	}
+	for _, tt := range tests {
+		assertion := &definitions.Assert{Key: tt.key, Relation: "approx", Value: tt.value, Tolerance: tt.tolerance}
+		result, err := AssertJob(assertion, definitions.NowDo())
+		if tt.pass != (err == nil) {
+			t.Errorf("expected %s approx %s (tolerance %s) pass to be %v, got %s: %v", tt.key, tt.value,
+				tt.tolerance, tt.pass, result, err)
+		}
+	}
+	assertion := &definitions.Assert{Key: "1", Relation: "approx", Value: "1"}
+	if _, err := AssertJob(assertion, definitions.NowDo()); err == nil {
+		t.Errorf("expected approx without a tolerance to return an error")
+	}
+}
+
+func TestAssertJobNotNumeric(t *testing.T) {
+	assertion := &definitions.Assert{Key: "marmot", Relation: "gt", Value: "10"}
+	result, err := AssertJob(assertion, definitions.NowDo())
+	if err == nil {
+		t.Errorf("expected ordering a non-numeric key to return an error, got %s", result)
+	}
+}
+
+const storedABI = `[{"anonymous":false,"inputs":[{"indexed":false,"name":"value","type":"uint256"}],"name":"Stored","type":"event"}]`
+
+func TestReceiptVariables(t *testing.T) {
+	topic, err := abi.Topic("Stored(uint256)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := &evm_events.EventDataLog{
+		Topics: []binary.Word256{binary.LeftPadWord256(topic)},
+		Data:   word(42),
+	}
+	res := &txResult{
+		TxResult:    &rpc.TxResult{Return: []byte{0x2a}},
+		Contract:    acm.Address{1},
+		Logs:        []*evm_events.EventDataLog{stored},
+		GasUsed:     21000,
+		BlockHeight: 7,
+	}
+	vars := receiptVariables(res, storedABI, abi.BytesHex, nil)
+	if vars[0].Name != "status" || vars[0].Value != txOk {
+		t.Errorf("expected the status ok first, got %s %s", vars[0].Name, vars[0].Value)
+	}
+	values := variableValues(vars)
+	for name, value := range map[string]string{
+		"height":                 "7",
+		"gas.used":               "21000",
+		"return":                 "2A",
+		"exception":              "",
+		"contract":               acm.Address{1}.String(),
+		"events":                 "[Stored]",
+		"events.Stored[0].value": "42",
+	} {
+		if values[name] != value {
+			t.Errorf("expected %s to be %q, got %q", name, value, values[name])
+		}
+	}
+
+	reverted := &txResult{TxResult: &rpc.TxResult{Exception: "Execution reverted"}}
+	values = variableValues(receiptVariables(reverted, "", abi.BytesHex, nil))
+	if values["status"] != txReverted || values["exception"] != "Execution reverted" {
+		t.Errorf("expected a reverted status and exception, got %q and %q", values["status"], values["exception"])
+	}
+	if _, ok := values["events"]; ok {
+		t.Errorf("expected no events without an abi")
+	}
+}
+
+func TestBlockVariables(t *testing.T) {
+	block := &burrow_rpc.ResultGetBlock{
+		BlockMeta: &tm_types.BlockMeta{
+			BlockID: tm_types.BlockID{Hash: []byte{0xab, 0xcd}},
+			Header: &tm_types.Header{
+				Height:         12,
+				Time:           time.Date(2018, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)),
+				NumTxs:         2,
+				ValidatorsHash: []byte{0x01},
+			},
+		},
+	}
+	vars := blockVariables(block)
+	if vars[0].Name != "hash" || vars[0].Value != "ABCD" {
+		t.Errorf("expected the hash ABCD first, got %s %s", vars[0].Name, vars[0].Value)
+	}
+	values := variableValues(vars)
+	for name, value := range map[string]string{
+		"height": "12",
+		"time":   "2018-03-01T11:30:00Z",
+		"txs":    "2",
+	} {
+		if values[name] != value {
+			t.Errorf("expected %s to be %q, got %q", name, value, values[name])
+		}
+	}
+}
@@ -21,7 +21,9 @@ func SendJob(send *definitions.Send, do *definitions.Do) (string, error) {
 	// Process Variables
 	send.Source, _ = util.PreProcess(send.Source, do)
 	send.Destination, _ = util.PreProcess(send.Destination, do)
-	send.Amount, _ = util.PreProcess(send.Amount, do)
+	if err := preProcessNumbers(do, &send.Amount); err != nil {
+		return "", err
+	}
 
 	// Use Default
 	send.Source = useDefault(send.Source, do.Package.Account)
@@ -132,8 +134,9 @@ func registerNameTx(name *definitions.RegisterName, do *definitions.Do) (string,
 	name.Source, _ = util.PreProcess(name.Source, do)
 	name.Name, _ = util.PreProcess(name.Name, do)
 	name.Data, _ = util.PreProcess(name.Data, do)
-	name.Amount, _ = util.PreProcess(name.Amount, do)
-	name.Fee, _ = util.PreProcess(name.Fee, do)
+	if err := preProcessNumbers(do, &name.Amount, &name.Fee); err != nil {
+		return "", err
+	}
 
 	// Set Defaults
 	name.Source = useDefault(name.Source, do.Package.Account)
@@ -220,7 +223,9 @@ func PermissionJob(perm *definitions.Permission, do *definitions.Do) (string, er
 func BondJob(bond *definitions.Bond, do *definitions.Do) (string, error) {
 	// Process Variables
 	bond.Account, _ = util.PreProcess(bond.Account, do)
-	bond.Amount, _ = util.PreProcess(bond.Amount, do)
+	if err := preProcessNumbers(do, &bond.Amount); err != nil {
+		return "", err
+	}
 	bond.PublicKey, _ = util.PreProcess(bond.PublicKey, do)
 
 	// Use Defaults
@@ -350,6 +355,24 @@ func txFinalize(do *definitions.Do, tx interface{}) (string, error) {
 	return result, nil
 }
 
+// preProcessNumbers replaces the variables of amounts, fees and gas and writes them in decimal for
+// the transaction, so they may be given in any form util.ParseInteger reads. Unset values and auto
+// gas are left as they are
+func preProcessNumbers(do *definitions.Do, values ...*string) error {
+	for _, value := range values {
+		*value, _ = util.PreProcess(*value, do)
+		if *value == "" || *value == autoGas {
+			continue
+		}
+		n, err := util.ParseInteger(*value, util.PackageUnits(do))
+		if err != nil {
+			return err
+		}
+		*value = n.String()
+	}
+	return nil
+}
+
 func useDefault(thisOne, defaultOne string) string {
 	if thisOne == "" {
 		return defaultOne
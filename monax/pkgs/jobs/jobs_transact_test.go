@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+func TestPreProcessNumbers(t *testing.T) {
+	do := definitions.NowDo()
+	do.Package = &definitions.Package{
+		Jobs:  []*definitions.Job{{JobName: "fee", JobResult: "1e3"}},
+		Units: map[string]int{"token": 6},
+	}
+	amount, fee, gas, unset := "2.5 token", "$fee", autoGas, ""
+	if err := preProcessNumbers(do, &amount, &fee, &gas, &unset); err != nil {
+		t.Fatal(err)
+	}
+	if amount != "2500000" || fee != "1000" || gas != autoGas || unset != "" {
+		t.Errorf("unexpected numbers amount %s, fee %s, gas %s and unset %q", amount, fee, gas, unset)
+	}
+	gas = "1 marmot"
+	if err := preProcessNumbers(do, &gas); err == nil {
+		t.Errorf("expected an error for a number in an unknown unit")
+	}
+}
@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/logging/loggers"
+	compilers "github.com/monax/bosmarmot/compilers/perform"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// VerifyJob compiles a contract and checks that the code deployed at an address is the runtime
+// code of the compiled contract
+func VerifyJob(verify *definitions.Verify, do *definitions.Do) (string, error) {
+	// Preprocess variables
+	verify.Contract, _ = util.PreProcess(verify.Contract, do)
+	verify.Instance, _ = util.PreProcess(verify.Instance, do)
+	verify.Address, _ = util.PreProcess(verify.Address, do)
+	verify.Libraries, _ = util.PreProcessLibs(verify.Libraries, do)
+
+	// Use defaults
+	contractName := strings.TrimSuffix(verify.Contract, filepath.Ext(verify.Contract))
+	verify.Instance = useDefault(verify.Instance, contractName)
+
+	resp, err := compilers.RequestCompile(verify.Contract, false, verify.Libraries)
+	if err != nil {
+		log.Errorln("Error compiling contracts: Compilers error:")
+		return "", err
+	} else if resp.Error != "" {
+		log.Errorln("Error compiling contracts: Language error:")
+		return "", fmt.Errorf("%v", resp.Error)
+	} else if resp.Warning != "" {
+		log.WithField("Warning", resp.Warning).Warn("Warning Generated during Contract Compilation")
+	}
+	var compiled string
+	for _, object := range resp.Objects {
+		if matchInstanceName(object.Objectname, verify.Instance) {
+			compiled = object.RuntimeBytecode
+			break
+		}
+	}
+	if compiled == "" {
+		return "", fmt.Errorf("could not find runtime code of %s in %s", verify.Instance, verify.Contract)
+	}
+	references, err := runtimeReferences(verify.Contract, verify.Instance)
+	if err != nil {
+		// without them immutables and unlinked libraries are compared as they are, so do not match
+		log.WithField("=>", err).Warn("Could not read the immutables and library references of the contract")
+	}
+
+	address, err := acm.AddressFromHexString(strings.TrimPrefix(verify.Address, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid address %s: %v", verify.Address, err)
+	}
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	account, err := nodeClient.GetAccount(address)
+	if err != nil {
+		return "", fmt.Errorf("could not get account %s: %v", verify.Address, err)
+	}
+	if account == nil || len(account.Code()) == 0 {
+		return "", fmt.Errorf("there is no contract at %s", verify.Address)
+	}
+
+	if err := compareRuntimeCode(compiled, fmt.Sprintf("%X", account.Code()), references); err != nil {
+		return "", fmt.Errorf("code at %s does not match %s: %v", verify.Address, verify.Instance, err)
+	}
+	log.WithFields(log.Fields{
+		"contract": verify.Instance,
+		"address":  verify.Address,
+	}).Warn("Verified Contract Code")
+	return "verified", nil
+}
+
+// stripMetadata drops the CBOR encoded metadata solc appends to the runtime code, whose length is
+// given by its last two bytes. The metadata holds a hash of the source files and compiler settings
+// so it differs between builds of the same code.
+func stripMetadata(code string) string {
+	if len(code) < 4 {
+		return code
+	}
+	length, err := strconv.ParseUint(code[len(code)-4:], 16, 16)
+	if err != nil {
+		return code
+	}
+	start := len(code) - 4 - 2*int(length)
+	if start < 0 {
+		return code
+	}
+	// the metadata is a CBOR map of one to five entries
+	if marker := strings.ToLower(code[start : start+2]); marker < "a1" || marker > "a5" {
+		return code
+	}
+	return code[:start]
+}
+
+// runtimeReferences are the parts of the runtime code of the instance which are filled in once it is
+// deployed, as solc reports them
+func runtimeReferences(contract, instance string) ([]compilers.CodeRange, error) {
+	if filepath.Ext(contract) != ".sol" {
+		return nil, nil
+	}
+	references, err := compilers.RuntimeReferences(contract, false)
+	if err != nil {
+		return nil, err
+	}
+	return instanceReferences(references, contract, instance)
+}
+
+// instanceReferences picks the references of the instance in the file of the contract from those
+// of every contract solc compiled, which include the contracts of imported files
+func instanceReferences(references map[string][]compilers.CodeRange, contract,
+	instance string) ([]compilers.CodeRange, error) {
+	for name, ranges := range references {
+		i := strings.LastIndex(name, ":")
+		if i >= 0 && name[:i] == contract && matchInstanceName(name, instance) {
+			return ranges, nil
+		}
+	}
+	return nil, fmt.Errorf("solc gave no references for %s in %s", instance, contract)
+}
+
+// compareRuntimeCode compares compiled runtime code (in hex) with the code of a deployed contract
+// (in hex). Besides the metadata, only the parts of the code which are filled in once deployed are
+// skipped: the immutables and libraries solc references, library placeholders left in the code and
+// the address libraries push at the start of their code to guard against being called directly.
+func compareRuntimeCode(compiled, deployed string, references []compilers.CodeRange) error {
+	compiled = strings.ToLower(stripMetadata(strings.TrimPrefix(compiled, "0x")))
+	deployed = strings.ToLower(stripMetadata(strings.TrimPrefix(deployed, "0x")))
+	if len(compiled) != len(deployed) {
+		return fmt.Errorf("deployed code is %d bytes long but the compiled code is %d bytes long",
+			len(deployed)/2, len(compiled)/2)
+	}
+
+	// the bytes of the code which are filled in once deployed
+	filled := make(map[int]bool)
+	fill := func(start, length int) {
+		for i := start; i < start+length; i++ {
+			filled[i] = true
+		}
+	}
+	for _, reference := range references {
+		end := reference.Start + reference.Length
+		if reference.Start < 0 || reference.Length < 0 || 2*end > len(compiled) {
+			return fmt.Errorf("solc references bytes %d to %d which are beyond the code", reference.Start, end)
+		}
+		// the compiled code holds zeros for immutables and a placeholder for libraries
+		if part := compiled[2*reference.Start : 2*end]; strings.Trim(part, "0") != "" && !strings.HasPrefix(part, "__") {
+			return fmt.Errorf("solc references byte %d which is not left blank in the compiled code", reference.Start)
+		}
+		fill(reference.Start, reference.Length)
+	}
+	for i := 0; i < len(compiled); {
+		j := strings.Index(compiled[i:], "__")
+		if j < 0 {
+			break
+		}
+		i += j
+		if i%2 != 0 || i+placeholderLength > len(compiled) {
+			return fmt.Errorf("malformed library placeholder at byte %d", i/2)
+		}
+		fill(i/2, placeholderLength/2)
+		i += placeholderLength
+	}
+	if strings.HasPrefix(compiled, "73"+strings.Repeat("0", placeholderLength)) && strings.HasPrefix(deployed, "73") {
+		fill(1, placeholderLength/2)
+	}
+
+	for i := 0; i < len(compiled)/2; i++ {
+		if !filled[i] && compiled[2*i:2*i+2] != deployed[2*i:2*i+2] {
+			return fmt.Errorf("code differs from byte %d", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	compilers "github.com/monax/bosmarmot/compilers/perform"
+)
+
+// the metadata solc 0.4 appends: a map holding the bzzr0 swarm hash of the metadata json
+func metadata(hash string) string {
+	return "a165627a7a72305820" + hash + "0029"
+}
+
+func TestStripMetadata(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	for _, test := range []struct {
+		code     string
+		expected string
+	}{
+		{"6060604052" + metadata(hash), "6060604052"},
+		{"6060604052", "6060604052"},
+		{"60" + "0029", "60" + "0029"},
+		{"", ""},
+	} {
+		if stripped := stripMetadata(test.code); stripped != test.expected {
+			t.Errorf("expected %s stripped to %s, got %s", test.code, test.expected, stripped)
+		}
+	}
+}
+
+func TestCompareRuntimeCode(t *testing.T) {
+	zeroWord := strings.Repeat("00", 32)
+	immutable := []compilers.CodeRange{{Start: 3, Length: 32}}
+	for _, test := range []struct {
+		name       string
+		compiled   string
+		deployed   string
+		references []compilers.CodeRange
+		match      bool
+	}{
+		{"same code", "6060604052f3", "6060604052F3", nil, true},
+		{"different metadata", "6060604052" + metadata(strings.Repeat("ab", 32)),
+			"0x6060604052" + metadata(strings.Repeat("cd", 32)), nil, true},
+		{"linked library", "73" + placeholder("Search") + "f3", "73" + searchAddress + "f3", nil, true},
+		{"library address guard", "73" + strings.Repeat("00", 20) + "3014", "73" + mathAddress + "3014", nil, true},
+		{"immutable", "607f" + "7f" + zeroWord + "f3", "607f" + "7f" + strings.Repeat("11", 32) + "f3", immutable, true},
+		{"zero word which is not an immutable", "607f" + "7f" + zeroWord + "f3",
+			"607f" + "7f" + strings.Repeat("11", 32) + "f3", nil, false},
+		{"zero word within push data", "7f" + strings.Repeat("00", 10) + "7f" + strings.Repeat("00", 21),
+			"7f" + strings.Repeat("00", 10) + "7f" + strings.Repeat("11", 21), nil, false},
+		{"reference to code which is not blank", "607f" + "7f" + strings.Repeat("22", 32) + "f3",
+			"607f" + "7f" + strings.Repeat("11", 32) + "f3", immutable, false},
+		{"reference beyond the code", "6060604052f3", "6060604052f3", immutable, false},
+		{"different code", "6060604052f3", "6060604053f3", nil, false},
+		{"different length", "6060604052f3", "6060604052", nil, false},
+		{"set word is not an immutable", "7f" + strings.Repeat("22", 32), "7f" + strings.Repeat("11", 32), nil, false},
+	} {
+		err := compareRuntimeCode(test.compiled, test.deployed, test.references)
+		if test.match && err != nil {
+			t.Errorf("%s: expected the code to match, got %v", test.name, err)
+		} else if !test.match && err == nil {
+			t.Errorf("%s: expected the code not to match", test.name)
+		}
+	}
+}
+
+func TestInstanceReferences(t *testing.T) {
+	references := map[string][]compilers.CodeRange{
+		"contracts/token.sol:Token": {{Start: 10, Length: 32}},
+		"lib/token.sol:Token":       {{Start: 20, Length: 32}},
+		"contracts/token.sol:Math":  {},
+	}
+	ranges, err := instanceReferences(references, "contracts/token.sol", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ranges, []compilers.CodeRange{{Start: 10, Length: 32}}) {
+		t.Errorf("expected the references of the Token of the contract file, got %v", ranges)
+	}
+	if _, err := instanceReferences(references, "contracts/other.sol", "Token"); err == nil {
+		t.Errorf("expected an error for an instance not in the contract file")
+	}
+}
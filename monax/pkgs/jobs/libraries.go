@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	compilers "github.com/monax/bosmarmot/compilers/perform"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// solc leaves a placeholder in the bytecode for every library which was not linked when compiling:
+// two underscores, the (possibly path qualified) library name and underscores up to the length of
+// an address in hex
+const placeholderLength = 40
+
+// libraryPlaceholders returns the names of the libraries the bytecode still needs linked, in the
+// order they first appear
+func libraryPlaceholders(code string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for i := strings.Index(code, "__"); i >= 0; i = strings.Index(code, "__") {
+		if len(code) < i+placeholderLength {
+			return nil, fmt.Errorf("truncated library placeholder %s in bytecode", code[i:])
+		}
+		name := strings.Trim(code[i:i+placeholderLength], "_")
+		if strings.HasPrefix(name, "$") {
+			return nil, fmt.Errorf("library placeholder %s is a hash of the library name which cannot be resolved, "+
+				"please link the library with the libraries field", code[i:i+placeholderLength])
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		code = code[i+placeholderLength:]
+	}
+	return names, nil
+}
+
+// linkBytecode replaces the placeholders of the libraries with their addresses
+func linkBytecode(code string, libraries map[string]string) string {
+	var linked []string
+	for i := strings.Index(code, "__"); i >= 0 && len(code) >= i+placeholderLength; i = strings.Index(code, "__") {
+		placeholder := code[i : i+placeholderLength]
+		address, ok := libraries[strings.Trim(placeholder, "_")]
+		if !ok {
+			address = placeholder
+		}
+		linked = append(linked, code[:i], address)
+		code = code[i+placeholderLength:]
+	}
+	return strings.Join(linked, "") + code
+}
+
+// libraryName drops the path solc qualifies the library names of placeholders with
+func libraryName(placeholder string) string {
+	parts := strings.Split(placeholder, ":")
+	return parts[len(parts)-1]
+}
+
+// deployedContracts are the addresses of the contracts deployed while running a package by their
+// contract name in lower case, whatever instance the deploy job gave, so that libraries deployed by
+// earlier jobs are linked rather than deployed again. RunJobs starts each run with none
+var deployedContracts = make(map[string]string)
+
+// findLibrary looks for the address of a library in the libraries of the package and then amongst
+// the contracts deployed by the jobs which have already run
+func findLibrary(name string, do *definitions.Do) string {
+	if address, ok := do.Package.Libraries[name]; ok {
+		address, _ = util.PreProcess(address, do)
+		return address
+	}
+	return deployedContracts[strings.ToLower(name)]
+}
+
+// linkLibraries links the libraries a contract needs which were not given in the libraries field of
+// the deploy job. Libraries are taken from the libraries of the package or the results of earlier
+// deploy jobs, and otherwise deployed from the compiled objects (objects is nil for binaries). The
+// addresses of newly deployed libraries are added to the libraries of the package so they are
+// deployed only once. The linked bytecode is returned along with the libraries it was linked to.
+func linkLibraries(jobName string, deploy *definitions.Deploy, do *definitions.Do, code string,
+	objects []compilers.ResponseItem) (string, map[string]string, error) {
+
+	placeholders, err := libraryPlaceholders(code)
+	if err != nil || len(placeholders) == 0 {
+		return code, nil, err
+	}
+
+	linked := make(map[string]string)
+	for _, placeholder := range placeholders {
+		name := libraryName(placeholder)
+		address := findLibrary(name, do)
+		if address == "" {
+			if address, err = deployLibrary(jobName, deploy, do, name, objects); err != nil {
+				return "", nil, err
+			}
+			if do.Package.Libraries == nil {
+				do.Package.Libraries = make(map[string]string)
+			}
+			do.Package.Libraries[name] = address
+		}
+		address = strings.TrimPrefix(address, "0x")
+		if len(address) != placeholderLength {
+			return "", nil, fmt.Errorf("cannot link library %s to %s, it is not an address", name, address)
+		}
+		log.WithFields(log.Fields{
+			"library": name,
+			"address": address,
+		}).Warn("Linking Library")
+		linked[placeholder] = address
+	}
+
+	return linkBytecode(code, linked), linked, nil
+}
+
+// deployLibrary deploys a library the contract being deployed needs from the same compilation
+func deployLibrary(jobName string, deploy *definitions.Deploy, do *definitions.Do, name string,
+	objects []compilers.ResponseItem) (string, error) {
+
+	for _, object := range objects {
+		if !matchInstanceName(object.Objectname, name) || object.Bytecode == "" {
+			continue
+		}
+		log.WithField("=>", name).Warn("Deploying Missing Library")
+		library := &definitions.Deploy{
+			Source:          deploy.Source,
+			Contract:        deploy.Contract,
+			Instance:        name,
+			Amount:          "0",
+			Fee:             deploy.Fee,
+			Gas:             deploy.Gas,
+			SkipIfUnchanged: deploy.SkipIfUnchanged,
+		}
+		address, _, err := deployContract(jobName, library, do, object, objects)
+		if err != nil {
+			return "", fmt.Errorf("could not deploy library %s: %v", name, err)
+		}
+		return address, nil
+	}
+	return "", fmt.Errorf("library %s is not linked, it is not in the libraries of the package nor deployed "+
+		"by an earlier job and its code is not available to deploy it", name)
+}
+
+// libraryVariables records the libraries a contract was linked to as libraries.<name>, which are
+// also written to the jobs output
+func libraryVariables(linked map[string]string) []*definitions.Variable {
+	var placeholders []string
+	for placeholder := range linked {
+		placeholders = append(placeholders, placeholder)
+	}
+	sort.Strings(placeholders)
+
+	var vars []*definitions.Variable
+	for _, placeholder := range placeholders {
+		vars = append(vars, &definitions.Variable{
+			Name:  "libraries." + libraryName(placeholder),
+			Value: linked[placeholder],
+		})
+	}
+	return vars
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+const (
+	searchAddress = "1040E6521541DAA7E2D8B0B8D68A46D9C8A7E0B5"
+	mathAddress   = "58FD1799AA32DED3F6EAC096A1DC77834A446B9C"
+)
+
+// bytecode calling the Search library twice and the basicMath library once
+var unlinkedCode = "6060604052" + "73" + placeholder("single-lib.sol:Search") + "6300" +
+	"73" + placeholder("basicMath") + "73" + placeholder("single-lib.sol:Search") + "f3"
+
+func placeholder(name string) string {
+	return "__" + name + strings.Repeat("_", placeholderLength-2-len(name))
+}
+
+func TestLibraryPlaceholders(t *testing.T) {
+	names, err := libraryPlaceholders(unlinkedCode)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"single-lib.sol:Search", "basicMath"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected placeholders %v, got %v", expected, names)
+	}
+
+	if names, err := libraryPlaceholders("6060604052f3"); err != nil || len(names) != 0 {
+		t.Errorf("expected no placeholders in linked code, got %v: %v", names, err)
+	}
+	if _, err := libraryPlaceholders("6060__$cb901161e812ceb78cfe30ca65050c4337$__f3"); err == nil {
+		t.Errorf("expected an error for a hashed placeholder")
+	}
+	if _, err := libraryPlaceholders("6060__Search__"); err == nil {
+		t.Errorf("expected an error for a truncated placeholder")
+	}
+}
+
+func TestLinkBytecode(t *testing.T) {
+	linked := linkBytecode(unlinkedCode, map[string]string{
+		"single-lib.sol:Search": searchAddress,
+		"basicMath":             mathAddress,
+	})
+	expected := "6060604052" + "73" + searchAddress + "6300" + "73" + mathAddress + "73" + searchAddress + "f3"
+	if linked != expected {
+		t.Errorf("expected %s, got %s", expected, linked)
+	}
+
+	partial := linkBytecode(unlinkedCode, map[string]string{"basicMath": mathAddress})
+	if names, _ := libraryPlaceholders(partial); !reflect.DeepEqual(names, []string{"single-lib.sol:Search"}) {
+		t.Errorf("expected only Search to be left unlinked, got %v", names)
+	}
+}
+
+func TestLinkLibrariesFromPackage(t *testing.T) {
+	do := definitions.NowDo()
+	do.Package = &definitions.Package{
+		Libraries: map[string]string{"Search": "0x" + searchAddress},
+	}
+	// deployed by an earlier job from a file of another name, which the instance defaults to
+	deployedContracts = map[string]string{"basicmath": mathAddress}
+	defer func() { deployedContracts = make(map[string]string) }()
+
+	code, linked, err := linkLibraries("deployC", &definitions.Deploy{}, do, unlinkedCode, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names, _ := libraryPlaceholders(code); len(names) != 0 {
+		t.Errorf("expected all libraries to be linked, %v are not", names)
+	}
+	vars := libraryVariables(linked)
+	if len(vars) != 2 || vars[0].Name != "libraries.basicMath" || vars[0].Value != mathAddress ||
+		vars[1].Name != "libraries.Search" || vars[1].Value != searchAddress {
+		t.Errorf("unexpected library variables %v", vars)
+	}
+
+	do.Package.Libraries = nil
+	deployedContracts = make(map[string]string)
+	if _, _, err := linkLibraries("deployC", &definitions.Deploy{}, do, unlinkedCode, nil); err == nil {
+		t.Errorf("expected an error when a library cannot be found nor deployed")
+	}
+}
@@ -0,0 +1,325 @@
+package jobs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/binary"
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/hyperledger/burrow/execution/evm/asm"
+	"github.com/hyperledger/burrow/execution/evm/asm/bc"
+	"github.com/hyperledger/burrow/keys"
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/monax/bosmarmot/monax/definitions"
+	"github.com/monax/bosmarmot/monax/log"
+	"github.com/monax/bosmarmot/monax/util"
+)
+
+// The proxy keeps the address of its implementation and of its admin in the storage slots of
+// EIP-1967, keccak256("eip1967.proxy.implementation") - 1 and keccak256("eip1967.proxy.admin") - 1,
+// so they cannot clash with the storage of the implementation
+var (
+	implementationSlot = mustDecodeHex("360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	adminSlot          = mustDecodeHex("b53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+)
+
+// upgradeSelector is the selector of upgradeTo(address), which the proxy handles itself when called
+// by its admin rather than forwarding it
+var upgradeSelector = crypto.Keccak256([]byte("upgradeTo(address)"))[:4]
+
+// proxyReturnSize is the size of the buffer the proxy returns what the implementation returned in.
+// The EVM of the chain has no RETURNDATASIZE so the size of the return cannot be known, returns
+// longer than this are truncated.
+const proxyReturnSize = 0x400
+
+// proxyRuntime assembles the code of the proxy. Calls to upgradeTo(address) from the admin store the
+// new implementation, any other call is forwarded to the implementation with a delegatecall which
+// runs the implementation's code against the storage of the proxy. The proxy throws when the
+// implementation throws.
+func proxyRuntime() []byte {
+	returnCode := bc.MustSplice(
+		asm.JUMPDEST, asm.PUSH2, uint16Bytes(proxyReturnSize), asm.PUSH1, 0, asm.RETURN,
+	)
+	upgradeCode := bc.MustSplice(
+		asm.JUMPDEST, asm.PUSH1, 4, asm.CALLDATALOAD, asm.PUSH32, implementationSlot, asm.SSTORE, asm.STOP,
+	)
+	dispatch := func(upgradeDest int) []byte {
+		// selector == upgradeTo(address) && caller == admin
+		return bc.MustSplice(
+			asm.PUSH1, 0, asm.CALLDATALOAD, asm.PUSH29, 1, make([]byte, 28), asm.SWAP1, asm.DIV,
+			asm.PUSH4, upgradeSelector, asm.EQ,
+			asm.PUSH32, adminSlot, asm.SLOAD, asm.CALLER, asm.EQ, asm.AND,
+			asm.PUSH2, uint16Bytes(upgradeDest), asm.JUMPI,
+		)
+	}
+	forward := func(returnDest int) []byte {
+		return bc.MustSplice(
+			asm.CALLDATASIZE, asm.PUSH1, 0, asm.PUSH1, 0, asm.CALLDATACOPY,
+			asm.PUSH2, uint16Bytes(proxyReturnSize), asm.PUSH1, 0, asm.CALLDATASIZE, asm.PUSH1, 0,
+			asm.PUSH32, implementationSlot, asm.SLOAD, asm.PUSH1, 0x40, asm.GAS, asm.SUB, asm.DELEGATECALL,
+			asm.PUSH2, uint16Bytes(returnDest), asm.JUMPI,
+			// throw by jumping to an invalid destination
+			asm.PUSH1, 0, asm.JUMP,
+		)
+	}
+	// the lengths do not depend on the destinations
+	returnDest := len(dispatch(0)) + len(forward(0))
+	upgradeDest := returnDest + len(returnCode)
+	return bc.MustSplice(dispatch(upgradeDest), forward(returnDest), returnCode, upgradeCode)
+}
+
+// proxyCode assembles the code deploying a proxy to the implementation with the deployer as admin
+func proxyCode(implementation acm.Address) []byte {
+	runtime := proxyRuntime()
+	assemble := func(runtimeOffset int) []byte {
+		return bc.MustSplice(
+			asm.CALLER, asm.PUSH32, adminSlot, asm.SSTORE,
+			asm.PUSH20, implementation.Bytes(), asm.PUSH32, implementationSlot, asm.SSTORE,
+			asm.PUSH2, uint16Bytes(len(runtime)), asm.DUP1, asm.PUSH2, uint16Bytes(runtimeOffset), asm.PUSH1, 0,
+			asm.CODECOPY, asm.PUSH1, 0, asm.RETURN,
+		)
+	}
+	deploy := assemble(0)
+	return append(assemble(len(deploy)), runtime...)
+}
+
+func uint16Bytes(n int) []byte {
+	return []byte{byte(n >> 8), byte(n)}
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// checkImplementationData rejects constructor arguments for an implementation, whose constructor
+// runs against the storage of the implementation rather than of the proxy
+func checkImplementationData(data interface{}) error {
+	if data != nil {
+		return fmt.Errorf("the constructor of an implementation cannot initialise the proxy, remove the data " +
+			"and initialise the proxy with a call job to it instead")
+	}
+	return nil
+}
+
+// upgradeData is the call which points a proxy to a new implementation
+func upgradeData(implementation acm.Address) string {
+	return hex.EncodeToString(bc.MustSplice(upgradeSelector, implementation.Word256().Bytes()))
+}
+
+// ProxyJob deploys an implementation contract and a proxy forwarding to it. The abi of the
+// implementation is saved under the address of the proxy so later jobs can call the proxy.
+func ProxyJob(proxy *definitions.Proxy, do *definitions.Do) (string, []*definitions.Variable, error) {
+	if err := checkImplementationData(proxy.Data); err != nil {
+		return "", nil, err
+	}
+
+	// Preprocess variables
+	proxy.Source, _ = util.PreProcess(proxy.Source, do)
+	if err := preProcessNumbers(do, &proxy.Fee, &proxy.Gas); err != nil {
+		return "", nil, err
+	}
+
+	// Use defaults
+	proxy.Source = useDefault(proxy.Source, do.Package.Account)
+	proxy.Fee = useDefault(proxy.Fee, do.DefaultFee)
+	proxy.Gas = useDefault(proxy.Gas, do.DefaultGas)
+
+	jobName := proxyJobName(do, func(job *definitions.Job) bool { return job.Proxy == proxy })
+	implementation, variables, err := deployJob(jobName, &definitions.Deploy{
+		Source:    proxy.Source,
+		Contract:  proxy.Contract,
+		Instance:  proxy.Instance,
+		Libraries: proxy.Libraries,
+		Amount:    "0",
+		Fee:       proxy.Fee,
+		Gas:       proxy.Gas,
+	}, do)
+	if err != nil {
+		return "", nil, err
+	}
+	implementationAddress, err := acm.AddressFromHexString(strings.TrimPrefix(implementation, "0x"))
+	if err != nil {
+		return "", nil, fmt.Errorf("could not deploy the implementation of the proxy: %v", err)
+	}
+
+	// Don't use pubKey if account override
+	var oldKey string
+	if proxy.Source != do.Package.Account {
+		oldKey = do.PublicKey
+		do.PublicKey = ""
+	}
+	code := hex.EncodeToString(proxyCode(implementationAddress))
+	deploy := &definitions.Deploy{
+		Source: proxy.Source,
+		Amount: "0",
+		Fee:    proxy.Fee,
+		Gas:    proxy.Gas,
+	}
+	tx, _, err := deployRaw(do, deploy, "proxy", code)
+	if err != nil {
+		return "", nil, err
+	}
+	res, err := deployFinalize(do, deploy, "", tx)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not deploy the proxy: %v", err)
+	}
+	if proxy.Source != do.Package.Account {
+		do.PublicKey = oldKey
+	}
+	result := res.Address.String()
+	log.WithFields(log.Fields{
+		"proxy":          result,
+		"implementation": implementation,
+	}).Warn("Deployed Proxy")
+
+	deployment := &Deployment{
+		Job:          jobName,
+		Contract:     "proxy",
+		BytecodeHash: hashHex(code),
+	}
+	if err := recordDeployment(do, deployment.committed(res)); err != nil {
+		return "", nil, err
+	}
+	if err := saveProxyABI(do, implementation, result); err != nil {
+		return "", nil, err
+	}
+
+	proxy.Variables = append([]*definitions.Variable{{Name: "implementation", Value: implementation}}, variables...)
+	return result, proxy.Variables, nil
+}
+
+// UpgradeJob deploys a new implementation contract and points a proxy to it, after which the abi of
+// the new implementation is saved under the address of the proxy
+func UpgradeJob(upgrade *definitions.Upgrade, do *definitions.Do) (string, []*definitions.Variable, error) {
+	if err := checkImplementationData(upgrade.Data); err != nil {
+		return "", nil, err
+	}
+
+	// Preprocess variables
+	upgrade.Source, _ = util.PreProcess(upgrade.Source, do)
+	upgrade.Proxy, _ = util.PreProcess(upgrade.Proxy, do)
+	if err := preProcessNumbers(do, &upgrade.Fee, &upgrade.Gas); err != nil {
+		return "", nil, err
+	}
+
+	// Use defaults
+	upgrade.Source = useDefault(upgrade.Source, do.Package.Account)
+	upgrade.Fee = useDefault(upgrade.Fee, do.DefaultFee)
+	upgrade.Gas = useDefault(upgrade.Gas, do.DefaultGas)
+
+	proxyAddress, err := acm.AddressFromHexString(strings.TrimPrefix(upgrade.Proxy, "0x"))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid proxy address %s: %v", upgrade.Proxy, err)
+	}
+
+	jobName := proxyJobName(do, func(job *definitions.Job) bool { return job.Upgrade == upgrade })
+	implementation, variables, err := deployJob(jobName, &definitions.Deploy{
+		Source:    upgrade.Source,
+		Contract:  upgrade.Contract,
+		Instance:  upgrade.Instance,
+		Libraries: upgrade.Libraries,
+		Amount:    "0",
+		Fee:       upgrade.Fee,
+		Gas:       upgrade.Gas,
+	}, do)
+	if err != nil {
+		return "", nil, err
+	}
+	implementationAddress, err := acm.AddressFromHexString(strings.TrimPrefix(implementation, "0x"))
+	if err != nil {
+		return "", nil, fmt.Errorf("could not deploy the new implementation of the proxy: %v", err)
+	}
+
+	// Don't use pubKey if account override
+	var oldKey string
+	if upgrade.Source != do.Package.Account {
+		oldKey = do.PublicKey
+		do.PublicKey = ""
+	}
+	callData := upgradeData(implementationAddress)
+	gas := upgrade.Gas
+	if gas == autoGas {
+		if gas, _, err = estimateGas(do, upgrade.Source, upgrade.Proxy, callData, ""); err != nil {
+			return "", nil, err
+		}
+	}
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	keyClient := keys.NewKeyClient(do.Signer, loggers.NewNoopInfoTraceLogger())
+	tx, err := rpc.Call(nodeClient, keyClient, do.PublicKey, upgrade.Source, upgrade.Proxy, "0", "", gas,
+		upgrade.Fee, callData)
+	if err != nil {
+		return "", nil, err
+	}
+	res, err := signAndBroadcast(do, tx)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not upgrade proxy %s: %v", upgrade.Proxy, err)
+	}
+	if res.Exception != "" {
+		return "", nil, fmt.Errorf("could not upgrade proxy %s: %s", upgrade.Proxy, res.Exception)
+	}
+	if upgrade.Source != do.Package.Account {
+		do.PublicKey = oldKey
+	}
+
+	// calls to upgradeTo by anyone but the admin are forwarded, so check the proxy was upgraded
+	current, err := proxyImplementation(do, proxyAddress)
+	if err != nil {
+		return "", nil, err
+	}
+	if current != implementationAddress {
+		return "", nil, fmt.Errorf("proxy %s was not upgraded, %s is not its admin", upgrade.Proxy, upgrade.Source)
+	}
+	log.WithFields(log.Fields{
+		"proxy":          upgrade.Proxy,
+		"implementation": implementation,
+	}).Warn("Upgraded Proxy")
+
+	if err := saveProxyABI(do, implementation, upgrade.Proxy); err != nil {
+		return "", nil, err
+	}
+
+	upgrade.Variables = append([]*definitions.Variable{{Name: "implementation", Value: implementation}}, variables...)
+	return upgrade.Proxy, upgrade.Variables, nil
+}
+
+// proxyImplementation reads the address of the implementation a proxy forwards to
+func proxyImplementation(do *definitions.Do, proxy acm.Address) (acm.Address, error) {
+	value, err := util.StorageInfo(proxy.String(), implementationSlot, do)
+	if err != nil {
+		return acm.ZeroAddress, err
+	}
+	implementation := acm.AddressFromWord256(binary.LeftPadWord256(value))
+	if implementation == acm.ZeroAddress {
+		return acm.ZeroAddress, fmt.Errorf("%s is not a proxy, it has no implementation", proxy)
+	}
+	return implementation, nil
+}
+
+// saveProxyABI saves the abi of the implementation, as saved when deploying it, under the address of
+// the proxy
+func saveProxyABI(do *definitions.Do, implementation, proxy string) error {
+	abiData, err := util.ReadAbi(do, implementation)
+	if err != nil {
+		return err
+	}
+	log.WithField("=>", proxy).Warn("Saving Implementation ABI For Proxy")
+	return util.NewABIStore(do.ABIPath, do.ChainID).SaveAddress(proxy, abiData)
+}
+
+// proxyJobName finds the name of the proxy or upgrade job, the implementations are recorded under it
+func proxyJobName(do *definitions.Do, match func(*definitions.Job) bool) string {
+	for _, job := range do.Package.Jobs {
+		if match(job) {
+			return job.JobName
+		}
+	}
+	return ""
+}
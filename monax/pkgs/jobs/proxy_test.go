@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	acm "github.com/hyperledger/burrow/account"
+	"github.com/hyperledger/burrow/binary"
+	"github.com/hyperledger/burrow/execution/evm"
+	"github.com/hyperledger/burrow/execution/evm/asm"
+	"github.com/hyperledger/burrow/execution/evm/asm/bc"
+	"github.com/hyperledger/burrow/logging/loggers"
+	"github.com/hyperledger/burrow/permission"
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+type testState struct {
+	accounts map[acm.Address]acm.Account
+	storage  map[acm.Address]map[binary.Word256]binary.Word256
+}
+
+func newTestState() *testState {
+	state := &testState{
+		accounts: make(map[acm.Address]acm.Account),
+		storage:  make(map[acm.Address]map[binary.Word256]binary.Word256),
+	}
+	state.UpdateAccount(acm.ConcreteAccount{
+		Address:     permission.GlobalPermissionsAddress,
+		Permissions: permission.AllAccountPermissions,
+	}.Account())
+	return state
+}
+
+func (state *testState) GetAccount(address acm.Address) (acm.Account, error) {
+	return state.accounts[address], nil
+}
+
+func (state *testState) UpdateAccount(account acm.Account) error {
+	state.accounts[account.Address()] = account
+	return nil
+}
+
+func (state *testState) RemoveAccount(address acm.Address) error {
+	delete(state.accounts, address)
+	return nil
+}
+
+func (state *testState) GetStorage(address acm.Address, key binary.Word256) (binary.Word256, error) {
+	return state.storage[address][key], nil
+}
+
+func (state *testState) SetStorage(address acm.Address, key, value binary.Word256) error {
+	if state.storage[address] == nil {
+		state.storage[address] = make(map[binary.Word256]binary.Word256)
+	}
+	state.storage[address][key] = value
+	return nil
+}
+
+// account adds an account, the addresses up to 8 are taken by native contracts
+func (state *testState) account(address byte, code []byte) acm.MutableAccount {
+	account := acm.ConcreteAccount{
+		Address: acm.AddressFromWord256(binary.LeftPadWord256([]byte{address})),
+		Code:    code,
+	}.MutableAccount()
+	state.UpdateAccount(account)
+	return account
+}
+
+// implementation code writing 1 to slot 0 and returning the word ret
+func implementationCode(ret byte) []byte {
+	return bc.MustSplice(asm.PUSH1, 1, asm.PUSH1, 0, asm.SSTORE,
+		asm.PUSH1, ret, asm.PUSH1, 0, asm.MSTORE, asm.PUSH1, 32, asm.PUSH1, 0, asm.RETURN)
+}
+
+func TestProxySlots(t *testing.T) {
+	for name, slot := range map[string][]byte{
+		"eip1967.proxy.implementation": implementationSlot,
+		"eip1967.proxy.admin":          adminSlot,
+	} {
+		hash := crypto.Keccak256([]byte(name))
+		hash[len(hash)-1]--
+		if !bytes.Equal(hash, slot) {
+			t.Errorf("slot of %s should be %X, got %X", name, hash, slot)
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	state := newTestState()
+	admin := state.account(0x11, nil)
+	user := state.account(0x12, nil)
+	implementationA := state.account(0x13, implementationCode(0xaa))
+	implementationB := state.account(0x14, implementationCode(0xbb))
+	proxy := state.account(0x15, nil)
+	vm := evm.NewVM(state, evm.DefaultDynamicMemoryProvider, evm.Params{}, admin.Address(), nil,
+		loggers.NewNoopInfoTraceLogger())
+
+	call := func(caller acm.MutableAccount, input []byte) ([]byte, error) {
+		gas := uint64(100000)
+		return vm.Call(caller, proxy, proxy.Code(), input, 0, &gas)
+	}
+	returned := func(caller acm.MutableAccount, expected byte) {
+		ret, err := call(caller, []byte{1, 2, 3, 4})
+		if err != nil {
+			t.Fatalf("unexpected error calling the proxy: %v", err)
+		}
+		if len(ret) != proxyReturnSize || ret[31] != expected || ret[32] != 0 {
+			t.Errorf("expected the proxy to return %X padded to %d bytes, got %X", expected, proxyReturnSize, ret)
+		}
+	}
+
+	gas := uint64(100000)
+	runtime, err := vm.Call(admin, proxy, proxyCode(implementationA.Address()), nil, 0, &gas)
+	if err != nil {
+		t.Fatalf("unexpected error deploying the proxy: %v", err)
+	}
+	if !bytes.Equal(runtime, proxyRuntime()) {
+		t.Fatalf("expected the proxy runtime code to be deployed, got %X", runtime)
+	}
+	proxy.SetCode(runtime)
+
+	returned(user, 0xaa)
+	if value := state.storage[proxy.Address()][binary.Zero256]; value != binary.LeftPadWord256([]byte{1}) {
+		t.Errorf("expected the implementation to write to the storage of the proxy, got %v", value)
+	}
+
+	upgrade := mustDecodeHex(upgradeData(implementationB.Address()))
+	if _, err := call(user, upgrade); err != nil {
+		t.Fatalf("unexpected error forwarding upgradeTo: %v", err)
+	}
+	returned(user, 0xaa)
+
+	if _, err := call(admin, upgrade); err != nil {
+		t.Fatalf("unexpected error upgrading: %v", err)
+	}
+	returned(user, 0xbb)
+
+	thrower := state.account(0x16, bc.MustSplice(asm.PUSH1, 0, asm.JUMP))
+	if _, err := call(admin, mustDecodeHex(upgradeData(thrower.Address()))); err != nil {
+		t.Fatalf("unexpected error upgrading: %v", err)
+	}
+	if _, err := call(user, nil); err == nil {
+		t.Errorf("expected the proxy to throw when the implementation throws")
+	}
+}
+
+func TestProxyRejectsData(t *testing.T) {
+	do := definitions.NowDo()
+	proxy := &definitions.Proxy{Contract: "counter.sol", Data: []interface{}{1}}
+	if _, _, err := ProxyJob(proxy, do); err == nil || !strings.Contains(err.Error(), "cannot initialise the proxy") {
+		t.Errorf("expected data for the implementation of a proxy to be rejected, got %v", err)
+	}
+	upgrade := &definitions.Upgrade{Proxy: "00", Contract: "counter.sol", Data: []interface{}{1}}
+	if _, _, err := UpgradeJob(upgrade, do); err == nil || !strings.Contains(err.Error(), "cannot initialise the proxy") {
+		t.Errorf("expected data for a new implementation of a proxy to be rejected, got %v", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/hyperledger/burrow/client"
+	"github.com/hyperledger/burrow/logging/loggers"
 	"github.com/monax/bosmarmot/monax/definitions"
 	"github.com/monax/bosmarmot/monax/loaders"
 	"github.com/monax/bosmarmot/monax/log"
@@ -48,6 +50,9 @@ func RunPackage(do *definitions.Do) error {
 		if do.ABIPath == "./abi" {
 			do.ABIPath = filepath.Join(do.Path, "abi")
 		}
+		if do.DeploymentsPath == "./deployments" {
+			do.DeploymentsPath = filepath.Join(do.Path, "deployments")
+		}
 		// TODO enable this feature
 		// if do.ContractsPath == "./contracts" {
 		//do.ContractsPath = filepath.Join(do.Path, "contracts")
@@ -74,6 +79,12 @@ func RunPackage(do *definitions.Do) error {
 		}
 	}
 
+	// the abis and deployments of deployed contracts are kept per chain
+	nodeClient := client.NewBurrowNodeClient(do.ChainURL, loggers.NewNoopInfoTraceLogger())
+	if _, do.ChainID, _, err = nodeClient.ChainId(); err != nil {
+		log.WithField("=>", err).Warn("Could not get the chain id, abis and deployments will not be saved for deployed contracts")
+	}
+
 	return jobs.RunJobs(do)
 }
 
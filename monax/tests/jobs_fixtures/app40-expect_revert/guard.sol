@@ -0,0 +1,15 @@
+pragma solidity >=0.0.0;
+
+contract Guard {
+	uint public x;
+
+	function Guard(uint start) {
+		if (start == 0) throw;
+		x = start;
+	}
+
+	function set(uint v) {
+		if (v > 10) throw;
+		x = v;
+	}
+}
@@ -0,0 +1,20 @@
+pragma solidity >=0.0.0;
+
+contract Token {
+	event Created(uint supply);
+	event Transfer(address indexed from, address indexed to, uint value);
+	event Note(string text);
+
+	function Token() {
+		Created(1000);
+	}
+
+	function transfer(address to, uint value) {
+		Transfer(msg.sender, to, value);
+		Transfer(to, msg.sender, value + 1);
+	}
+
+	function note(string text) {
+		Note(text);
+	}
+}
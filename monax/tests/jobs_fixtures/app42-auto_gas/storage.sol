@@ -0,0 +1,19 @@
+pragma solidity >=0.0.0;
+
+contract Storage {
+	uint[] values;
+
+	function Storage(uint n) {
+		fill(n);
+	}
+
+	function fill(uint n) {
+		for (uint i = 0; i < n; i++) {
+			values.push(i);
+		}
+	}
+
+	function count() constant returns (uint) {
+		return values.length;
+	}
+}
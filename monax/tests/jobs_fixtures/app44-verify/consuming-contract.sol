@@ -0,0 +1,21 @@
+pragma solidity >=0.0.0;
+
+import "./single-lib.sol";
+
+contract C {
+    using Search for uint[];
+    uint[] data;
+
+    function append(uint value) {
+        data.push(value);
+    }
+
+    function replace(uint _old, uint _new) {
+        // This performs the library function call
+        uint index = data.indexOf(_old);
+        if (index == uint(-1))
+            data.push(_new);
+        else
+            data[index] = _new;
+    }
+}
\ No newline at end of file
@@ -0,0 +1,13 @@
+pragma solidity >=0.0.0;
+
+contract storage {
+  int storedData;
+
+  function set(int x) {
+    storedData = x;
+  }
+
+  function get() constant returns (int retVal) {
+    return storedData;
+  }
+}
@@ -0,0 +1,29 @@
+pragma solidity >=0.0.0;
+
+contract Counter {
+  uint count;
+
+  function increment() {
+    count += 1;
+  }
+
+  function get() constant returns (uint) {
+    return count;
+  }
+}
+
+contract CounterV2 {
+  uint count;
+
+  function increment() {
+    count += 1;
+  }
+
+  function add(uint value) {
+    count += value;
+  }
+
+  function get() constant returns (uint) {
+    return count;
+  }
+}
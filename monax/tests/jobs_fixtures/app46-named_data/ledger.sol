@@ -0,0 +1,31 @@
+pragma solidity >=0.0.0;
+
+contract Ledger {
+  address owner;
+  uint total;
+
+  function Ledger(address _owner, uint _total) {
+    owner = _owner;
+    total = _total;
+  }
+
+  function record(uint amount, bool credit, uint[] fees) {
+    uint sum = amount;
+    for (uint i = 0; i < fees.length; i++) {
+      sum += fees[i];
+    }
+    if (credit) {
+      total += sum;
+    } else {
+      total -= sum;
+    }
+  }
+
+  function getTotal() constant returns (uint) {
+    return total;
+  }
+
+  function getOwner() constant returns (address) {
+    return owner;
+  }
+}
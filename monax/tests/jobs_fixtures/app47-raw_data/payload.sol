@@ -0,0 +1,9 @@
+pragma solidity >=0.0.0;
+
+contract Payload {
+  uint public size;
+
+  function() {
+    size = msg.data.length;
+  }
+}
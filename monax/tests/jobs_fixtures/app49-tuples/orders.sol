@@ -0,0 +1,29 @@
+pragma solidity >=0.4.19;
+pragma experimental ABIEncoderV2;
+
+contract Orders {
+  struct Order {
+    address owner;
+    uint[] prices;
+    string note;
+  }
+
+  Order[] orders;
+
+  function place(Order order) public {
+    // copied field by field as older compilers cannot copy structs holding arrays to storage
+    orders.length++;
+    Order storage placed = orders[orders.length - 1];
+    placed.owner = order.owner;
+    placed.prices = order.prices;
+    placed.note = order.note;
+  }
+
+  function get(uint index) public view returns (Order order, uint count) {
+    return (orders[index], orders.length);
+  }
+
+  function all() public view returns (Order[]) {
+    return orders;
+  }
+}
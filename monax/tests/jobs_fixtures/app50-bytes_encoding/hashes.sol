@@ -0,0 +1,11 @@
+pragma solidity >=0.0.0;
+
+contract Hashes {
+  bytes32 public name;
+  bytes4 public tag;
+
+  function set(bytes32 _name, bytes4 _tag) {
+    name = _name;
+    tag = _tag;
+  }
+}
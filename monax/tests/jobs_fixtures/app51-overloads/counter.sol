@@ -0,0 +1,25 @@
+pragma solidity >=0.0.0;
+
+contract Counter {
+  uint public count;
+
+  function add() {
+    count += 1;
+  }
+
+  function add(uint n) {
+    count += n;
+  }
+
+  function add(uint n, uint times) {
+    count += n * times;
+  }
+
+  function get() constant returns (uint) {
+    return count;
+  }
+
+  function get(uint scale) constant returns (uint) {
+    return count * scale;
+  }
+}
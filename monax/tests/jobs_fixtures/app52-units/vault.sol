@@ -0,0 +1,14 @@
+pragma solidity >=0.0.0;
+
+contract Vault {
+  uint public limit;
+  uint public deposited;
+
+  function Vault(uint _limit) {
+    limit = _limit;
+  }
+
+  function deposit(uint amount) {
+    deposited += amount;
+  }
+}
@@ -0,0 +1,11 @@
+pragma solidity >=0.0.0;
+
+contract Store {
+  uint public value;
+  string public label;
+
+  function Store(uint _value, string _label) {
+    value = _value;
+    label = _label;
+  }
+}
@@ -0,0 +1,19 @@
+pragma solidity >=0.0.0;
+
+contract Ledger {
+  uint total;
+  address owner;
+  int8 level;
+  bool open;
+  mapping (address => uint) balances;
+  mapping (string => uint) limits;
+
+  function Ledger(uint _total) {
+    total = _total;
+    owner = msg.sender;
+    level = -3;
+    open = true;
+    balances[msg.sender] = _total;
+    limits["marmot"] = 42;
+  }
+}
@@ -0,0 +1,13 @@
+pragma solidity >=0.0.0;
+
+contract Counter {
+	event Added(uint value, uint total);
+
+	uint total;
+
+	function add(uint value) returns (uint) {
+		total += value;
+		Added(value, total);
+		return total;
+	}
+}
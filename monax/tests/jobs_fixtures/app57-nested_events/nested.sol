@@ -0,0 +1,29 @@
+pragma solidity >=0.0.0;
+
+contract Inner {
+	event Stored(uint value);
+
+	uint stored;
+
+	function store(uint value) {
+		stored = value;
+		Stored(value);
+	}
+}
+
+contract Outer {
+	event Forwarding(uint value);
+	event Forwarded(uint value);
+
+	Inner inner;
+
+	function Outer(address innerAddress) {
+		inner = Inner(innerAddress);
+	}
+
+	function forward(uint value) {
+		Forwarding(value);
+		inner.store(value);
+		Forwarded(value);
+	}
+}
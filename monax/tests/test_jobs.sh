@@ -122,6 +122,8 @@ run_test(){
 
   rm -rf ./abi &>/dev/null
   rm -rf ./bin &>/dev/null
+  rm -rf ./deployments &>/dev/null
+  rm -rf ./state &>/dev/null
   rm ./epm.output.json &>/dev/null
   rm ./jobs_output.csv &>/dev/null
 
@@ -0,0 +1,229 @@
+package util
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+// ABIStore keeps the abis of compiled and deployed contracts in a directory:
+//
+//	artifacts/<hash>.json    the abis, by the keccak256 hash of their content
+//	names.json               fully qualified contract names (file:contract) to abi hashes
+//	chains/<chain id>.json   addresses of the contracts deployed to a chain to abi hashes
+//
+// so contracts of the same name from different files, or deployed to the same address on different
+// chains, do not overwrite each other's abi.
+type ABIStore struct {
+	Root    string
+	ChainID string
+}
+
+func NewABIStore(root, chainID string) *ABIStore {
+	return &ABIStore{Root: root, ChainID: chainID}
+}
+
+// ABIHash is the key of an abi in the store
+func ABIHash(abiData string) string {
+	return strings.ToUpper(common.Bytes2Hex(crypto.Keccak256([]byte(abiData))))
+}
+
+// Save stores the abi of a contract under its fully qualified name and returns its hash
+func (store *ABIStore) Save(name, abiData string) (string, error) {
+	hash, err := store.saveArtifact(abiData)
+	if err != nil {
+		return "", err
+	}
+	names, err := store.Names()
+	if err != nil {
+		return "", err
+	}
+	names[name] = hash
+	return hash, writeIndex(filepath.Join(store.Root, "names.json"), names)
+}
+
+// SaveAddress stores the abi of the contract deployed to an address of the chain
+func (store *ABIStore) SaveAddress(address, abiData string) error {
+	if store.ChainID == "" {
+		return fmt.Errorf("cannot save the abi of %s without knowing the chain it is deployed to", address)
+	}
+	hash, err := store.saveArtifact(abiData)
+	if err != nil {
+		return err
+	}
+	addresses, err := store.Addresses(store.ChainID)
+	if err != nil {
+		return err
+	}
+	addresses[addressKey(address)] = hash
+	return writeIndex(store.chainFile(store.ChainID), addresses)
+}
+
+// Read finds an abi by, in order, the address of a contract on the chain, the fully qualified
+// name of a contract, the name of a contract (when only one abi is stored for contracts of that
+// name) or the hash of the abi. Files placed directly in the root, such as abis stored by earlier
+// versions, are read by their file name.
+func (store *ABIStore) Read(key string) (string, error) {
+	if store.ChainID != "" && isAddress(key) {
+		addresses, err := store.Addresses(store.ChainID)
+		if err != nil {
+			return "", err
+		}
+		if hash, ok := addresses[addressKey(key)]; ok {
+			return store.readArtifact(hash)
+		}
+	}
+
+	names, err := store.Names()
+	if err != nil {
+		return "", err
+	}
+	if hash, ok := names[key]; ok {
+		return store.readArtifact(hash)
+	}
+	var matches []string
+	hashes := make(map[string]bool)
+	for name, hash := range names {
+		if contractPart(name) == key {
+			matches = append(matches, name)
+			hashes[hash] = true
+		}
+	}
+	if len(hashes) > 1 {
+		sort.Strings(matches)
+		return "", fmt.Errorf("there are different abis for contracts named %s, use one of %s",
+			key, strings.Join(matches, ", "))
+	} else if len(matches) > 0 {
+		return store.readArtifact(names[matches[0]])
+	}
+
+	if _, err := hex.DecodeString(key); err == nil && len(key) == 64 {
+		if abiData, err := store.readArtifact(strings.ToUpper(key)); err == nil {
+			return abiData, nil
+		}
+	}
+
+	p := filepath.Join(store.Root, stripHex(key))
+	if _, err := os.Stat(p); err != nil {
+		return "", fmt.Errorf("Abi doesn't exist for =>\t%s", key)
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Names returns the fully qualified names of the stored contracts with the hashes of their abis
+func (store *ABIStore) Names() (map[string]string, error) {
+	return readIndex(filepath.Join(store.Root, "names.json"))
+}
+
+// Addresses returns the addresses of the contracts deployed to a chain with the hashes of their abis
+func (store *ABIStore) Addresses(chainID string) (map[string]string, error) {
+	return readIndex(store.chainFile(chainID))
+}
+
+// Chains returns the ids of the chains contracts were deployed to
+func (store *ABIStore) Chains() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(store.Root, "chains"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var chains []string
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == ".json" {
+			chains = append(chains, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+	return chains, nil
+}
+
+func (store *ABIStore) chainFile(chainID string) string {
+	return filepath.Join(store.Root, "chains", chainID+".json")
+}
+
+func (store *ABIStore) saveArtifact(abiData string) (string, error) {
+	hash := ABIHash(abiData)
+	file := filepath.Join(store.Root, "artifacts", hash+".json")
+	if _, err := os.Stat(file); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0775); err != nil {
+		return "", err
+	}
+	return hash, ioutil.WriteFile(file, []byte(abiData), 0664)
+}
+
+func (store *ABIStore) readArtifact(hash string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(store.Root, "artifacts", hash+".json"))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func readIndex(file string) (map[string]string, error) {
+	index := make(map[string]string)
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return index, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("could not read abi index %s: %v", file, err)
+	}
+	return index, nil
+}
+
+func writeIndex(file string, index map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0775); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, b, 0664)
+}
+
+func isAddress(key string) bool {
+	key = strings.TrimPrefix(key, "0x")
+	if len(key) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+func addressKey(address string) string {
+	return strings.ToUpper(strings.TrimPrefix(address, "0x"))
+}
+
+func contractPart(name string) string {
+	parts := strings.Split(name, ":")
+	return parts[len(parts)-1]
+}
+
+// QualifiedName is the name a contract compiled from a file is stored under, the file being
+// relative to the package directory when it is inside it
+func QualifiedName(do *definitions.Do, file, contract string) string {
+	if do.Path != "" {
+		if rel, err := filepath.Rel(do.Path, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file) + ":" + contract
+}
@@ -0,0 +1,108 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+const (
+	storageABI = `[{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"","type":"int256"}],"type":"function"}]`
+	otherABI   = `[{"constant":false,"inputs":[],"name":"set","outputs":[],"type":"function"}]`
+	address    = "1040E6521541DAA7E2D8B0B8D68A46D9C8A7E0B5"
+)
+
+func TestABIStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	chainA := NewABIStore(root, "chain-a")
+	chainB := NewABIStore(root, "chain-b")
+	hash, err := chainA.Save("storage.sol:Storage", storageABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != ABIHash(storageABI) {
+		t.Errorf("expected the abi to be stored by its hash %s, got %s", ABIHash(storageABI), hash)
+	}
+	if _, err := chainA.Save("other/storage.sol:Storage", otherABI); err != nil {
+		t.Fatal(err)
+	}
+	if err := chainA.SaveAddress("0x"+address, storageABI); err != nil {
+		t.Fatal(err)
+	}
+	if err := chainB.SaveAddress(address, otherABI); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewABIStore(root, "").SaveAddress(address, otherABI); err == nil {
+		t.Errorf("expected an error saving an address without a chain")
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "Legacy"), []byte(otherABI), 0664); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		store    *ABIStore
+		key      string
+		expected string
+	}{
+		{chainA, address, storageABI},
+		{chainA, "0x" + strings.ToLower(address), storageABI},
+		{chainB, address, otherABI},
+		{chainA, "storage.sol:Storage", storageABI},
+		{chainA, "other/storage.sol:Storage", otherABI},
+		{chainB, hash, storageABI},
+		{chainA, "Legacy", otherABI},
+	} {
+		abiData, err := test.store.Read(test.key)
+		if err != nil {
+			t.Errorf("unexpected error reading %s from %s: %v", test.key, test.store.ChainID, err)
+		} else if abiData != test.expected {
+			t.Errorf("expected %s from %s to be %s, got %s", test.key, test.store.ChainID, test.expected, abiData)
+		}
+	}
+
+	if _, err := chainA.Read("Storage"); err == nil {
+		t.Errorf("expected an error reading an ambiguous contract name")
+	}
+	if _, err := chainA.Read("Missing"); err == nil {
+		t.Errorf("expected an error reading a missing abi")
+	}
+	if _, err := chainA.Save("storage.sol:Other", storageABI); err != nil {
+		t.Fatal(err)
+	}
+	if abiData, err := chainA.Read("Other"); err != nil || abiData != storageABI {
+		t.Errorf("expected to read the abi by contract name, got %s: %v", abiData, err)
+	}
+
+	chains, err := chainA.Chains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chains) != 2 || chains[0] != "chain-a" || chains[1] != "chain-b" {
+		t.Errorf("expected chains [chain-a chain-b], got %v", chains)
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	do := definitions.NowDo()
+	do.Path = "/app"
+	for _, test := range []struct {
+		file, expected string
+	}{
+		{"/app/contracts/storage.sol", "contracts/storage.sol:Storage"},
+		{"storage.sol", "storage.sol:Storage"},
+		{"/elsewhere/storage.sol", "/elsewhere/storage.sol:Storage"},
+	} {
+		if name := QualifiedName(do, test.file, "Storage"); name != test.expected {
+			t.Errorf("expected %s to be qualified as %s, got %s", test.file, test.expected, name)
+		}
+	}
+}
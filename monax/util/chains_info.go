@@ -10,6 +10,8 @@ import (
 	acm "github.com/hyperledger/burrow/account"
 	"github.com/hyperledger/burrow/client"
 	"github.com/hyperledger/burrow/logging/loggers"
+	tm_client "github.com/hyperledger/burrow/rpc/tm/client"
+	rpcclient "github.com/tendermint/tendermint/rpc/lib/client"
 )
 
 func GetBlockHeight(do *definitions.Do) (latestBlockHeight uint64, err error) {
@@ -111,6 +113,20 @@ func ValidatorsInfo(field string, do *definitions.Do) (string, error) {
 	return strings.Join(vals, ","), nil
 }
 
+// StorageInfo reads the word of the storage of a contract at the key
+func StorageInfo(account string, key []byte, do *definitions.Do) ([]byte, error) {
+	address, err := acm.AddressFromHexString(account)
+	if err != nil {
+		return nil, fmt.Errorf("Account Addr %s is improper hex: %v", account, err)
+	}
+	value, err := tm_client.GetStorage(rpcclient.NewJSONRPCClient(do.ChainURL), address, key)
+	if err != nil {
+		return nil, err
+	}
+	// the node leaves out the leading zeros of the word
+	return append(make([]byte, 32-len(value)), value...), nil
+}
+
 func itoaU64(i uint64) string {
 	return strconv.FormatUint(i, 10)
 }
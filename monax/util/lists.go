@@ -0,0 +1,45 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuoteElement quotes a string or bytes element of an array or tuple, as in JSON, when it would
+// otherwise be taken for more than one element or for a nested array or tuple: when it is empty,
+// has spaces around it or holds a comma, bracket, quote or backslash. Other elements are left as
+// they are
+func QuoteElement(element string) string {
+	if element == "" || strings.TrimSpace(element) != element || strings.ContainsAny(element, `,()[]"\`) {
+		return strconv.Quote(element)
+	}
+	return element
+}
+
+// UnquoteElement reads an element of an array or tuple quoted by QuoteElement. Elements which are
+// not quoted are returned as they are
+func UnquoteElement(element string) (string, error) {
+	if !strings.HasPrefix(element, `"`) {
+		return element, nil
+	}
+	unquoted, err := strconv.Unquote(element)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a properly quoted string: %v", element, err)
+	}
+	return unquoted, nil
+}
+
+// QuotedLength is the length of the quoted string at the start of the value, including its quotes,
+// or -1 when the quotes are not closed
+func QuotedLength(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
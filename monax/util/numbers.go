@@ -0,0 +1,98 @@
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+// the most a number may be scaled by, well beyond the 78 digits of a uint256, so a typo such as
+// 1e1000000 fails rather than allocating a huge number
+const maxExponent = 1000
+
+var decimalNumber = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?(?:[eE]([+-]?[0-9]+))?$`)
+
+// ParseInteger reads an integer written in decimal, as 0x prefixed hex, with underscores between
+// its digits (1_000_000), in scientific notation (1e18, 2.5e6) or followed by one of the units,
+// such as 5 token, which scales it by ten to the decimals of the unit. The units are those of the
+// package, keyed by lower case name, and may be nil
+func ParseInteger(value string, units map[string]int) (*big.Int, error) {
+	r, err := ParseNumber(value, units)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, fmt.Errorf("%s is not an integer", value)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// ParseNumber reads a number written in any of the forms ParseInteger reads, which may also have a
+// scale left once any exponent and unit are applied, such as 1.5 or 25e-3, exactly
+func ParseNumber(value string, units map[string]int) (*big.Rat, error) {
+	number := strings.TrimSpace(value)
+	decimals := 0
+	if fields := strings.Fields(number); len(fields) == 2 {
+		var ok bool
+		if decimals, ok = units[strings.ToLower(fields[1])]; !ok {
+			return nil, fmt.Errorf("%s is not a number: there is no unit %s", value, fields[1])
+		}
+		number = fields[0]
+	}
+
+	negative := strings.HasPrefix(number, "-")
+	number = strings.TrimPrefix(strings.TrimPrefix(number, "-"), "+")
+	if strings.HasPrefix(number, "_") || strings.HasSuffix(number, "_") || strings.Contains(number, "__") {
+		return nil, fmt.Errorf("%s is not a number: underscores may only separate digits", value)
+	}
+	number = strings.Replace(number, "_", "", -1)
+
+	n := new(big.Int)
+	exponent := decimals
+	if strings.HasPrefix(number, "0x") {
+		if _, ok := n.SetString(number[2:], 16); !ok {
+			return nil, fmt.Errorf("%s is not a number", value)
+		}
+	} else {
+		parts := decimalNumber.FindStringSubmatch(number)
+		if parts == nil {
+			return nil, fmt.Errorf("%s is not a number", value)
+		}
+		exponent -= len(parts[2])
+		if parts[3] != "" {
+			e, err := strconv.Atoi(parts[3])
+			if err != nil || e > maxExponent || e < -maxExponent {
+				return nil, fmt.Errorf("%s is not a number: the exponent is out of range", value)
+			}
+			exponent += e
+		}
+		if exponent > maxExponent {
+			return nil, fmt.Errorf("%s is not a number: the exponent is out of range", value)
+		}
+		n.SetString(parts[1]+parts[2], 10)
+	}
+	if negative {
+		n.Neg(n)
+	}
+	r := new(big.Rat).SetInt(n)
+	if exponent >= 0 {
+		return r.Mul(r, new(big.Rat).SetInt(pow10(exponent))), nil
+	}
+	return r.Quo(r, new(big.Rat).SetInt(pow10(-exponent))), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// PackageUnits are the units amounts and integer arguments may be given in by the package
+func PackageUnits(do *definitions.Do) map[string]int {
+	if do.Package == nil {
+		return nil
+	}
+	return do.Package.Units
+}
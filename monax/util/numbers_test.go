@@ -0,0 +1,82 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestParseInteger(t *testing.T) {
+	units := map[string]int{"token": 18, "finney": 15, "unit": 0}
+	for _, test := range []struct {
+		value, expected string
+	}{
+		{"42", "42"},
+		{"-42", "-42"},
+		{"+7", "7"},
+		{"0x2A", "42"},
+		{"0xff", "255"},
+		{"1_000_000", "1000000"},
+		{"1e18", "1000000000000000000"},
+		{"2.5E6", "2500000"},
+		{"15e-1", ""},
+		{"150e-1", "15"},
+		{"5 token", "5000000000000000000"},
+		{"1.5 Token", "1500000000000000000"},
+		{"-2 finney", "-2000000000000000"},
+		{"1e3 unit", "1000"},
+		{"0x10 unit", "16"},
+		{" 12 ", "12"},
+		{"1.5", ""},
+		{"5 tokens", ""},
+		{"_1", ""},
+		{"1__0", ""},
+		{"1_", ""},
+		{"0xmarmot", ""},
+		{"marmot", ""},
+		{"1e1000000", ""},
+		{"", ""},
+	} {
+		n, err := ParseInteger(test.value, units)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("expected an error reading %q, got %s", test.value, n)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error reading %q: %v", test.value, err)
+		} else if n.String() != test.expected {
+			t.Errorf("expected %q to be read as %s, got %s", test.value, test.expected, n)
+		}
+	}
+
+	if _, err := ParseInteger("5 token", nil); err == nil {
+		t.Errorf("expected an error reading a unit without units")
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	units := map[string]int{"token": 3}
+	for _, test := range []struct {
+		value, expected string
+	}{
+		{"1.50", "3/2"},
+		{"-0.25", "-1/4"},
+		{"25e-3", "1/40"},
+		{"1_000.5", "2001/2"},
+		{"1.0005 token", "2001/2"},
+		{"0x10", "16"},
+		{"0010", "10"},
+		{"1.", ""},
+		{"0X10", ""},
+		{"1.5.2", ""},
+	} {
+		r, err := ParseNumber(test.value, units)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("expected an error reading %q, got %s", test.value, r.RatString())
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error reading %q: %v", test.value, err)
+		} else if r.RatString() != test.expected {
+			t.Errorf("expected %q to be read as %s, got %s", test.value, test.expected, r.RatString())
+		}
+	}
+}
@@ -2,11 +2,9 @@ package util
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path"
 
 	"github.com/hyperledger/burrow/client/rpc"
+	"github.com/monax/bosmarmot/monax/definitions"
 	"github.com/monax/bosmarmot/monax/log"
 )
 
@@ -47,18 +45,9 @@ func ReadTxSignAndBroadcast(result *rpc.TxResult, err error) error {
 	return nil
 }
 
-func ReadAbi(root, contract string) (string, error) {
-	p := path.Join(root, stripHex(contract))
-	if _, err := os.Stat(p); err != nil {
-		return "", fmt.Errorf("Abi doesn't exist for =>\t%s", p)
-	}
-
-	b, err := ioutil.ReadFile(p)
-	if err != nil {
-		return "", err
-	}
-
-	return string(b), nil
+// ReadAbi reads an abi from the abi store of the package, see ABIStore.Read
+func ReadAbi(do *definitions.Do, contract string) (string, error) {
+	return NewABIStore(do.ABIPath, do.ChainID).Read(contract)
 }
 
 // TODO use go-ethereum/common
@@ -1,8 +1,6 @@
 package util
 
 import (
-	"fmt"
-	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -14,7 +12,8 @@ import (
 func PreProcess(toProcess string, do *definitions.Do) (string, error) {
 	// $block.... $account.... etc. should be caught. hell$$o should not
 	// :$libAddr needs to be caught
-	catchEr := regexp.MustCompile(`(^|\s|:)\$([a-zA-Z0-9_.]+)`)
+	// $call.events.Transfer[0].value indexes into the events of a call, [$a,$b] should stop at the ]
+	catchEr := regexp.MustCompile(`(^|\s|:)\$([a-zA-Z0-9_.]+(?:\[[0-9]+\][a-zA-Z0-9_.]*)*)`)
 	// If there's a match then run through the replacement process
 	if catchEr.MatchString(toProcess) {
 		log.WithField("match", toProcess).Debug("Replacement Match Found")
@@ -44,7 +43,7 @@ func PreProcess(toProcess string, do *definitions.Do) (string, error) {
 
 			if strings.Contains(jobName, ".") { //for functions with multiple returns
 				wantsInnerValues = true
-				var splitStr = strings.Split(jobName, ".")
+				var splitStr = strings.SplitN(jobName, ".", 2)
 				jobName = splitStr[0]
 				innerVarName = splitStr[1]
 			}
@@ -132,68 +131,6 @@ func replaceBlockVariable(toReplace string, do *definitions.Do) (string, error)
 	return toReplace, nil
 }
 
-func PreProcessInputData(function string, data interface{}, do *definitions.Do, constructor bool) (string, []string, error) {
-	var callDataArray []string
-	var callArray []string
-	if function == "" && !constructor {
-		if reflect.TypeOf(data).Kind() == reflect.Slice {
-			return "", []string{""}, fmt.Errorf("Incorrect formatting of epm.yaml. Please update it to include a function field.")
-		}
-		function = strings.Split(data.(string), " ")[0]
-		callArray = strings.Split(data.(string), " ")[1:]
-		for _, val := range callArray {
-			output, _ := PreProcess(val, do)
-			callDataArray = append(callDataArray, output)
-		}
-	} else if data != nil {
-		if reflect.TypeOf(data).Kind() != reflect.Slice {
-			if constructor {
-				log.Warn("Deprecation Warning: Your deploy job is currently using a soon to be deprecated way of declaring constructor values. Please remember to update your run file to store them as a array rather than a string. See documentation for further details.")
-				callArray = strings.Split(data.(string), " ")
-				for _, val := range callArray {
-					output, _ := PreProcess(val, do)
-					callDataArray = append(callDataArray, output)
-				}
-				return function, callDataArray, nil
-			} else {
-				return "", make([]string, 0), fmt.Errorf("Incorrect formatting of epm.yaml file. Please update it to include a function field.")
-			}
-		}
-		val := reflect.ValueOf(data)
-		for i := 0; i < val.Len(); i++ {
-			s := val.Index(i)
-			var newString string
-			switch s.Interface().(type) {
-			case bool:
-				newString = strconv.FormatBool(s.Interface().(bool))
-			case int, int32, int64:
-				newString = strconv.FormatInt(int64(s.Interface().(int)), 10)
-			case []interface{}:
-				var args []string
-				for _, index := range s.Interface().([]interface{}) {
-					value := reflect.ValueOf(index)
-					var stringified string
-					switch value.Kind() {
-					case reflect.Int:
-						stringified = strconv.FormatInt(value.Int(), 10)
-					case reflect.String:
-						stringified = value.String()
-					}
-					index, _ = PreProcess(stringified, do)
-					args = append(args, stringified)
-				}
-				newString = "[" + strings.Join(args, ",") + "]"
-				log.Debug(newString)
-			default:
-				newString = s.Interface().(string)
-			}
-			newString, _ = PreProcess(newString, do)
-			callDataArray = append(callDataArray, newString)
-		}
-	}
-	return function, callDataArray, nil
-}
-
 func PreProcessLibs(libs string, do *definitions.Do) (string, error) {
 	libraries, _ := PreProcess(libs, do)
 	if libraries != "" {
@@ -204,8 +141,12 @@ func PreProcessLibs(libs string, do *definitions.Do) (string, error) {
 	return libraries, nil
 }
 
+// GetReturnValue is the value of the return, or of all the returns when there are several. The
+// variables for the fields and elements of returned tuples are left out, since the value of the
+// tuple itself holds them
 func GetReturnValue(vars []*definitions.Variable) string {
 	var result []string
+	vars = returnVariables(vars)
 
 	if len(vars) > 1 {
 		for _, value := range vars {
@@ -220,3 +161,14 @@ func GetReturnValue(vars []*definitions.Variable) string {
 		return ""
 	}
 }
+
+// returnVariables leaves out the variables nested in a return, named return.field or return[0]
+func returnVariables(vars []*definitions.Variable) []*definitions.Variable {
+	var returns []*definitions.Variable
+	for _, v := range vars {
+		if !strings.ContainsAny(v.Name, ".[") {
+			returns = append(returns, v)
+		}
+	}
+	return returns
+}
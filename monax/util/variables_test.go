@@ -0,0 +1,68 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/monax/bosmarmot/monax/definitions"
+)
+
+func TestPreProcessJobVariables(t *testing.T) {
+	do := definitions.NowDo()
+	do.Package = &definitions.Package{
+		Jobs: []*definitions.Job{
+			{
+				JobName:   "deployToken",
+				JobResult: "6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC",
+			},
+			{
+				JobName:   "transfer",
+				JobResult: "true",
+				JobVars: []*definitions.Variable{
+					{Name: "success", Value: "true"},
+					{Name: "events", Value: "[Transfer]"},
+					{Name: "events.Transfer[0].value", Value: "100"},
+				},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		in, out string
+	}{
+		{"$deployToken", "6AE6F6BD6A2ECC45AB40AE1C3DC7D8A1E5C0E8BC"},
+		{"$transfer", "true"},
+		{"$transfer.success", "true"},
+		{"$transfer.events", "[Transfer]"},
+		{"$transfer.events.Transfer[0].value", "100"},
+		{"send $transfer.events.Transfer[0].value tokens", "send 100 tokens"},
+		{"[1,$transfer]", "[1,$transfer]"},
+		{"hell$$o", "hell$$o"},
+	} {
+		actual, err := PreProcess(test.in, do)
+		if err != nil {
+			t.Fatalf("unexpected error processing %q: %v", test.in, err)
+		}
+		if actual != test.out {
+			t.Errorf("expected %q to be processed to %q, got %q", test.in, test.out, actual)
+		}
+	}
+}
+
+func TestGetReturnValue(t *testing.T) {
+	for _, test := range []struct {
+		vars     []*definitions.Variable
+		expected string
+	}{
+		{nil, ""},
+		{[]*definitions.Variable{{Name: "0", Value: "1"}}, "1"},
+		{[]*definitions.Variable{{Name: "a", Value: "1"}, {Name: "b", Value: "marmot"}}, "(1, marmot)"},
+		{[]*definitions.Variable{{Name: "item", Value: "(1,marmot)"}, {Name: "item.id", Value: "1"},
+			{Name: "item.label", Value: "marmot"}}, "(1,marmot)"},
+		{[]*definitions.Variable{{Name: "items", Value: "[(1)]"}, {Name: "0", Value: "true"},
+			{Name: "items[0]", Value: "(1)"}, {Name: "items[0].id", Value: "1"}}, "([(1)], true)"},
+	} {
+		if value := GetReturnValue(test.vars); value != test.expected {
+			t.Errorf("expected the return value %q, got %q", test.expected, value)
+		}
+	}
+}